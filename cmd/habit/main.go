@@ -2,20 +2,33 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	_ "time/tzdata" // Embedded time zone data so HABIT_TIMEZONE works on every platform
 
 	"github.com/codeforgood-org/cli-habit-tracker-go/internal/config"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/commands"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/remote"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
 const version = "2.0.0"
 
+// defaultSyncAddr is the address sync-server listens on unless --addr is
+// given. Only this machine can connect to it.
+const defaultSyncAddr = "127.0.0.1:8765"
+
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, storage.ErrLocked) {
+			fmt.Fprintln(os.Stderr, "Set HABIT_LOCK_TIMEOUT (e.g. 30s) to wait longer.")
+		}
 		os.Exit(1)
 	}
 }
@@ -29,10 +42,37 @@ func run() error {
 	}
 
 	// Load configuration
-	cfg := config.FromEnv()
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return err
+	}
+
+	// Count days in the configured time zone and from the configured hour
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+	clock := commands.Clock{Calendar: models.Calendar{Location: loc, DayStartHour: cfg.DayStartHour}}
+	if !cfg.Now.IsZero() {
+		clock.Source = &models.FixedClock{Time: cfg.Now}
+	}
 
 	// Initialize storage
-	store := storage.NewJSONStorage(cfg.DataFilePath)
+	opts := storage.Options{Clock: clock.Source, LockTimeout: cfg.LockTimeout}
+	store, err := storage.Open(cfg.DataURL, opts)
+	if err != nil {
+		return err
+	}
+
+	// Encrypted habits are decrypted transparently, finding out whether they
+	// are from what the command reads anyway
+	plain, passphrase := store, passphraseSource(cfg, false)
+	store = storage.NewOptionalEncryptedStorage(store, passphrase)
+
+	// Storage that works offline tells when changes are waiting for its server
+	if syncer, ok := plain.(interface{ SyncStatus() storage.SyncStatus }); ok {
+		defer reportSyncStatus(syncer.SyncStatus, plain.GetPath())
+	}
 
 	// Parse command
 	command := args[1]
@@ -40,14 +80,74 @@ func run() error {
 	// Route to appropriate handler
 	switch command {
 	case "list", "ls":
-		return commands.List(store)
+		_, flags, err := parseArgs(args[2:], map[string]bool{"tag": true, "archived": false})
+		if err != nil {
+			return err
+		}
+		_, archived := flags["archived"]
+		return commands.List(store, clock, commands.ListOptions{Tag: flags["tag"], Archived: archived})
+
+	case "add", "new":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{
+			"description": true, "schedule": true, "start": true, "color": true, "target": true, "unit": true,
+		})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		opts := commands.AddOptions{
+			Description: flags["description"],
+			Schedule:    flags["schedule"],
+			StartDate:   flags["start"],
+			Color:       flags["color"],
+			Unit:        flags["unit"],
+		}
+		if value, ok := flags["target"]; ok {
+			if opts.Target, err = models.ParseAmount(value); err != nil {
+				return err
+			}
+		}
+		habitName := strings.Join(positional, " ")
+		return commands.Add(store, clock, habitName, opts)
 
 	case "mark", "done":
-		if len(args) < 3 {
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"date": true, "yesterday": false, "amount": true, "note": true, "create": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
 			return fmt.Errorf("please provide a habit name")
 		}
-		habitName := strings.Join(args[2:], " ")
-		return commands.Mark(store, habitName)
+		date, err := dateFlag(flags)
+		if err != nil {
+			return err
+		}
+		_, create := flags["create"]
+		opts := commands.MarkOptions{Date: date, Note: flags["note"], Create: create}
+		if value, ok := flags["amount"]; ok {
+			if opts.Amount, err = models.ParseAmount(value); err != nil {
+				return err
+			}
+		}
+		habitName := strings.Join(positional, " ")
+		return commands.Mark(store, clock, habitName, opts)
+
+	case "unmark", "undo":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"date": true, "yesterday": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		date, err := dateFlag(flags)
+		if err != nil {
+			return err
+		}
+		habitName := strings.Join(positional, " ")
+		return commands.Unmark(store, clock, habitName, date)
 
 	case "delete", "del", "rm":
 		if len(args) < 3 {
@@ -56,23 +156,124 @@ func run() error {
 		habitName := strings.Join(args[2:], " ")
 		return commands.Delete(store, habitName)
 
+	case "archive":
+		if len(args) < 3 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(args[2:], " ")
+		return commands.Archive(store, habitName)
+
+	case "unarchive":
+		if len(args) < 3 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(args[2:], " ")
+		return commands.Unarchive(store, habitName)
+
+	case "pause":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"until": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(positional, " ")
+		return commands.Pause(store, clock, habitName, flags["until"])
+
+	case "resume":
+		if len(args) < 3 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(args[2:], " ")
+		return commands.Resume(store, clock, habitName)
+
+	case "vacation":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"from": true, "until": true})
+		if err != nil {
+			return err
+		}
+		action := "status"
+		if len(positional) > 0 {
+			action = positional[0]
+		}
+		switch action {
+		case "start":
+			return commands.StartVacation(store, clock, flags["from"], flags["until"])
+		case "end", "stop":
+			return commands.EndVacation(store, clock)
+		case "status":
+			return commands.VacationStatus(store, clock)
+		}
+		return fmt.Errorf("usage: habit vacation start [--from YYYY-MM-DD] [--until YYYY-MM-DD]\n       habit vacation end")
+
+	case "freeze":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"date": true, "yesterday": false, "add": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(positional, " ")
+		if value, ok := flags["add"]; ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid number of freezes '%s'", value)
+			}
+			return commands.AddFreezes(store, habitName, n)
+		}
+		date, err := dateFlag(flags)
+		if err != nil {
+			return err
+		}
+		return commands.Freeze(store, clock, habitName, date)
+
 	case "reset":
 		if len(args) < 3 {
 			return fmt.Errorf("please provide a habit name")
 		}
 		habitName := strings.Join(args[2:], " ")
-		return commands.Reset(store, habitName)
+		return commands.Reset(store, clock, habitName)
+
+	case "log", "history":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"git": false})
+		if err != nil {
+			return err
+		}
+		habitName := strings.Join(positional, " ")
+		if _, git := flags["git"]; git {
+			return commands.GitHistory(store, clock, habitName)
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		return commands.Log(store, habitName)
+
+	case "revert":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: habit revert <commit>")
+		}
+		return commands.Revert(store, args[2])
 
 	case "stats", "statistics":
-		return commands.Stats(store)
+		_, flags, err := parseArgs(args[2:], map[string]bool{"tag": true})
+		if err != nil {
+			return err
+		}
+		return commands.Stats(store, clock, commands.StatsOptions{Tag: flags["tag"]})
 
 	case "export":
-		if len(args) < 4 {
-			return fmt.Errorf("usage: habit export <format> <output-file>\n  formats: csv, json")
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"tag": true})
+		if err != nil {
+			return err
 		}
-		format := args[2]
-		outputPath := args[3]
-		return commands.Export(store, format, outputPath)
+		if len(positional) < 2 {
+			return fmt.Errorf("usage: habit export <format> <output-file> [--tag <tag>]\n  formats: csv, json")
+		}
+		format := positional[0]
+		outputPath := positional[1]
+		return commands.Export(store, clock, format, outputPath, commands.ExportOptions{Tag: flags["tag"]})
 
 	case "import":
 		if len(args) < 4 {
@@ -83,12 +284,53 @@ func run() error {
 		merge := len(args) > 4 && (args[4] == "--merge" || args[4] == "-m")
 		return commands.Import(store, format, inputPath, merge)
 
+	case "merge":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"output": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) != 3 {
+			return fmt.Errorf("usage: habit merge <base> <ours> <theirs> [--output <file>]")
+		}
+		return commands.Merge(store, clock, positional[0], positional[1], positional[2], flags["output"])
+
+	case "sync":
+		positional, _, err := parseArgs(args[2:], map[string]bool{})
+		if err != nil {
+			return err
+		}
+		serverURL := cfg.SyncURL
+		if len(positional) > 0 {
+			serverURL = positional[0]
+		}
+		if len(positional) > 1 || serverURL == "" {
+			return fmt.Errorf("usage: habit sync <server-url> (or set HABIT_SYNC_URL)")
+		}
+		return commands.Sync(store, clock, remote.NewClient(serverURL, cfg.SyncToken), cfg.SyncStatePath())
+
+	case "sync-server":
+		_, flags, err := parseArgs(args[2:], map[string]bool{"addr": true, "insecure": false})
+		if err != nil {
+			return err
+		}
+		addr := flags["addr"]
+		if addr == "" {
+			addr = defaultSyncAddr
+		}
+		_, insecure := flags["insecure"]
+		return commands.SyncServer(store, addr, cfg.SyncToken, insecure)
+
 	case "search", "find":
-		if len(args) < 3 {
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"notes": false, "tag": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
 			return fmt.Errorf("please provide a search query")
 		}
-		query := strings.Join(args[2:], " ")
-		return commands.Search(store, query)
+		query := strings.Join(positional, " ")
+		_, notes := flags["notes"]
+		return commands.Search(store, clock, query, commands.SearchOptions{Notes: notes, Tag: flags["tag"]})
 
 	case "edit", "rename":
 		if len(args) < 4 {
@@ -98,19 +340,122 @@ func run() error {
 		newName := strings.Join(args[3:], " ")
 		return commands.Edit(store, oldName, newName)
 
+	case "avoid", "quit":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"since": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) == 0 {
+			return fmt.Errorf("please provide a habit name")
+		}
+		habitName := strings.Join(positional, " ")
+		return commands.Avoid(store, clock, habitName, flags["since"])
+
+	case "schedule":
+		if len(args) < 4 {
+			return fmt.Errorf("usage: habit schedule <habit-name> <schedule>\n  schedules: daily, weekdays, weekends, mon,wed,fri, every:3, 3/week, 2/month")
+		}
+		habitName := strings.Join(args[2:len(args)-1], " ")
+		return commands.SetSchedule(store, clock, habitName, args[len(args)-1])
+
+	case "target":
+		// The target is the last number; words before it name the habit, words after it are the unit
+		targetIdx := -1
+		for i := len(args) - 1; i >= 3; i-- {
+			if _, err := models.ParseTarget(args[i]); err == nil {
+				targetIdx = i
+				break
+			}
+		}
+		if targetIdx < 0 {
+			return fmt.Errorf("usage: habit target <habit-name> <amount> [unit]\n  use an amount of 0 to remove the target")
+		}
+		target, err := models.ParseTarget(args[targetIdx])
+		if err != nil {
+			return err
+		}
+		habitName := strings.Join(args[2:targetIdx], " ")
+		unit := strings.Join(args[targetIdx+1:], " ")
+		return commands.SetTarget(store, habitName, target, unit)
+
+	case "tag":
+		if len(args) > 2 && args[2] == "list" {
+			return commands.ListTags(store)
+		}
+		if len(args) < 5 || (args[2] != "add" && args[2] != "remove" && args[2] != "rm") {
+			return fmt.Errorf("usage: habit tag add|remove <habit-name> <tag>\n       habit tag list")
+		}
+		habitName := strings.Join(args[3:len(args)-1], " ")
+		if args[2] == "add" {
+			return commands.AddTag(store, habitName, args[len(args)-1])
+		}
+		return commands.RemoveTag(store, habitName, args[len(args)-1])
+
 	case "backup":
 		backupPath := ""
 		if len(args) > 2 {
 			backupPath = args[2]
 		}
-		return commands.Backup(store, backupPath)
+		return commands.Backup(store, clock, backupPath)
 
 	case "restore":
 		if len(args) < 3 {
 			return fmt.Errorf("usage: habit restore <backup-file>")
 		}
 		backupPath := args[2]
-		return commands.Restore(store, backupPath)
+		return commands.Restore(store, clock, backupPath)
+
+	case "encrypt":
+		return commands.Encrypt(plain, passphraseSource(cfg, true))
+
+	case "decrypt":
+		return commands.Decrypt(plain, passphrase)
+
+	case "events":
+		habitName := ""
+		if len(args) > 2 {
+			habitName = strings.Join(args[2:], " ")
+		}
+		return commands.Events(store, clock, habitName)
+
+	case "migrate-storage":
+		positional, flags, err := parseArgs(args[2:], map[string]bool{"force": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) < 1 || len(positional) > 2 {
+			return fmt.Errorf("usage: habit migrate-storage <%s> [target-file] [--force]", strings.Join(backendNames(), "|"))
+		}
+		backend, err := storage.Lookup(positional[0])
+		if err != nil {
+			return err
+		}
+		_, location := storage.ParseURL(cfg.DataURL)
+		target := migrationTarget(location, backend)
+		if len(positional) == 2 {
+			target = positional[1]
+		}
+		to, err := storage.Open(storage.FormatURL(backend.Name, target), opts)
+		if err != nil {
+			return err
+		}
+		encrypted, err := storage.IsEncrypted(store)
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			to = storage.NewEncryptedStorage(to, passphrase)
+		}
+		_, force := flags["force"]
+		if err := commands.MigrateStorage(store, to, force); err != nil {
+			return err
+		}
+		location = to.GetPath()
+		if _, rest, ok := strings.Cut(location, "://"); ok {
+			location = rest // Remote storage such as WebDAV reports its full URL, without credentials
+		}
+		fmt.Printf("  To use it, set: export HABIT_DATA_URL=%s\n", storage.FormatURL(backend.Name, location))
+		return nil
 
 	case "version", "-v", "--version":
 		fmt.Printf("habit-tracker v%s\n", version)
@@ -126,23 +471,132 @@ func run() error {
 	}
 }
 
+// reportSyncStatus warns when the server of a storage could not be reached,
+// and lists conflicts resolved while saving changes made offline.
+func reportSyncStatus(status func() storage.SyncStatus, server string) {
+	s := status()
+	switch {
+	case s.Offline && s.Pending:
+		fmt.Fprintf(os.Stderr, "⚠ %s cannot be reached; changes are kept locally and saved once it can be\n", server)
+	case s.Offline:
+		fmt.Fprintf(os.Stderr, "⚠ %s cannot be reached; showing the habits cached locally\n", server)
+	}
+	if len(s.Conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d conflict(s) were resolved in favor of the changes made offline:\n", len(s.Conflicts))
+		for _, c := range s.Conflicts {
+			fmt.Fprintf(os.Stderr, "  - %s\n", c)
+		}
+	}
+}
+
+// migrationTarget suggests where migrate-storage writes to: the data file
+// with the extension of the target backend, such as .db for SQLite.
+func migrationTarget(dataFile string, backend *storage.Backend) string {
+	return strings.TrimSuffix(dataFile, filepath.Ext(dataFile)) + backend.Extension
+}
+
+// backendNames lists the names of the available storage backends.
+func backendNames() []string {
+	var names []string
+	for _, b := range storage.Backends() {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// parseArgs splits command arguments into positional arguments and --flags.
+// The spec maps each accepted flag name to whether it takes a value, given
+// either as "--flag value" or "--flag=value". Arguments after "--" are always
+// treated as positional.
+func parseArgs(args []string, spec map[string]bool) ([]string, map[string]string, error) {
+	var positional []string
+	flags := make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		takesValue, ok := spec[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown option: --%s", name)
+		}
+
+		if !takesValue {
+			if hasValue {
+				return nil, nil, fmt.Errorf("option --%s does not take a value", name)
+			}
+			flags[name] = "true"
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("option --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+
+	return positional, flags, nil
+}
+
+// dateFlag returns the date selected by the --date or --yesterday flags.
+func dateFlag(flags map[string]string) (string, error) {
+	date := flags["date"]
+	if _, ok := flags["yesterday"]; ok {
+		if date != "" {
+			return "", fmt.Errorf("--date and --yesterday cannot be used together")
+		}
+		date = "yesterday"
+	}
+	return date, nil
+}
+
 func printUsage() {
 	fmt.Println("Usage: habit <command> [arguments]")
 	fmt.Println()
 	fmt.Println("Core Commands:")
 	fmt.Println("  list              List all habits with their streaks")
-	fmt.Println("  mark <name>       Mark a habit as done for today")
+	fmt.Println("  add <name>        Start tracking a new habit")
+	fmt.Println("  mark <name>       Mark a habit as done (today or --date)")
+	fmt.Println("  unmark <name>     Remove a completion (today or --date)")
 	fmt.Println("  delete <name>     Delete a habit")
+	fmt.Println("  archive <name>    Hide a habit but keep its history")
+	fmt.Println("  pause <name>      Pause a habit without breaking its streak")
+	fmt.Println("  vacation start    Put all habits on vacation (vacation end to stop)")
+	fmt.Println("  freeze <name>     Use a freeze token to cover a missed day")
 	fmt.Println("  reset <name>      Reset a habit's streak")
+	fmt.Println("  log <name>        Show a habit's history with notes")
+	fmt.Println("  events [name]     Show recorded changes (event log storage)")
+	fmt.Println("  history --git     List git commits of the data file (git storage)")
 	fmt.Println("  stats             Show habit statistics")
 	fmt.Println()
 	fmt.Println("Advanced Commands:")
 	fmt.Println("  search <query>    Search for habits by name")
 	fmt.Println("  edit <old> <new>  Rename a habit")
+	fmt.Println("  avoid <name>      Track a habit you are quitting")
+	fmt.Println("  schedule <n> <s>  Set how often a habit is due")
+	fmt.Println("  target <n> <amt>  Set a daily target for a measurable habit")
+	fmt.Println("  tag add <n> <t>   Tag a habit (also: tag remove, tag list)")
 	fmt.Println("  export <fmt> <f>  Export habits (csv, json)")
 	fmt.Println("  import <fmt> <f>  Import habits (csv, json)")
+	fmt.Println("  merge <b> <o> <t> Merge two changed copies of a data file")
+	fmt.Println("  sync [url]        Sync habits with a sync server (also: sync-server)")
 	fmt.Println("  backup [file]     Backup habits data")
 	fmt.Println("  restore <file>    Restore from backup")
+	fmt.Println("  revert <commit>   Go back to a git commit (git storage)")
+	fmt.Println("  encrypt           Encrypt habits with a passphrase (also: decrypt)")
+	fmt.Printf("  migrate-storage   Copy habits to another backend (%s)\n", strings.Join(backendNames(), ", "))
 	fmt.Println()
 	fmt.Println("Other:")
 	fmt.Println("  version           Show version information")
@@ -158,42 +612,144 @@ func printHelp() {
 	fmt.Println("  habit <command> [arguments]")
 	fmt.Println()
 	fmt.Println("CORE COMMANDS:")
-	fmt.Println("  list, ls")
+	fmt.Println("  list [--tag <tag>] [--archived], ls")
 	fmt.Println("      List all tracked habits with their current streaks and last completion dates.")
+	fmt.Println("      With --tag, only habits with that tag are shown. With --archived, archived")
+	fmt.Println("      habits are shown instead. Each habit's ID is shown after it; wherever a")
+	fmt.Println("      habit name is expected, its ID or a unique prefix of 4+ characters also works.")
 	fmt.Println()
-	fmt.Println("  mark <habit-name>, done <habit-name>")
-	fmt.Println("      Mark a habit as completed for today. If the habit is new, it will be created.")
+	fmt.Println("  add <habit-name> [options], new <habit-name>")
+	fmt.Println("      Start tracking a new habit without marking it. Options:")
+	fmt.Println("        --description <text>   What the habit is about")
+	fmt.Println("        --schedule <schedule>  How often it is due (see schedule, default daily)")
+	fmt.Println("        --start <date>         When tracking starts (YYYY-MM-DD, default today)")
+	fmt.Println("        --color <color>        Color in list: " + strings.Join(models.Colors, ", "))
+	fmt.Println("        --target <amount>      Daily target for a measurable habit")
+	fmt.Println("        --unit <unit>          Unit of the target, e.g. glasses")
+	fmt.Println()
+	fmt.Println("  mark <habit-name> [--date YYYY-MM-DD | --yesterday], done <habit-name>")
+	fmt.Println("      Mark a habit as completed for today. Unknown names are rejected with a")
+	fmt.Println("      suggestion for the closest habit; use --create to add and mark a new habit.")
 	fmt.Println("      Streaks increment when you complete a habit on consecutive days.")
+	fmt.Println("      Use --date or --yesterday to log a missed day; the streak is recalculated.")
+	fmt.Println("      For habits with a target, add an amount (habit mark Water 3 or --amount 3);")
+	fmt.Println("      amounts add up and the day counts once the target is reached.")
+	fmt.Println("      Use --note \"text\" to attach a note to the completion.")
+	fmt.Println()
+	fmt.Println("  unmark <habit-name> [--date YYYY-MM-DD | --yesterday], undo <habit-name>")
+	fmt.Println("      Remove a single completion (today by default) and recalculate the streak")
+	fmt.Println("      from the remaining history.")
 	fmt.Println()
 	fmt.Println("  delete <habit-name>, del <habit-name>, rm <habit-name>")
 	fmt.Println("      Permanently delete a habit from tracking.")
 	fmt.Println()
+	fmt.Println("  archive <habit-name>, unarchive <habit-name>")
+	fmt.Println("      Hide a habit from list and stats without deleting it, or bring it back.")
+	fmt.Println("      Its history and streak are kept.")
+	fmt.Println()
+	fmt.Println("  pause <habit-name> [--until YYYY-MM-DD], resume <habit-name>")
+	fmt.Println("      Pause a habit from today through the --until date (or until resumed).")
+	fmt.Println("      Paused days are not due and do not break the streak.")
+	fmt.Println()
+	fmt.Println("  vacation start [--from YYYY-MM-DD] [--until YYYY-MM-DD], vacation end")
+	fmt.Println("      Put all habits on vacation, e.g. while travelling or sick. Vacation days do")
+	fmt.Println("      not break any streak. --from may be in the past to cover days already missed,")
+	fmt.Println("      or in the future to plan ahead. Habits added during a vacation follow it.")
+	fmt.Println("      Run 'habit vacation' to see whether a vacation is in effect or planned.")
+	fmt.Println()
+	fmt.Println("  freeze <habit-name> [--date YYYY-MM-DD | --yesterday], freeze <habit-name> --add N")
+	fmt.Println("      Spend a freeze token to cover a missed day (today by default) so it does not")
+	fmt.Println("      break the streak. Use --add to give a habit more freeze tokens.")
+	fmt.Println()
 	fmt.Println("  reset <habit-name>")
-	fmt.Println("      Reset a habit's streak to zero and clear its completion date.")
+	fmt.Println("      Reset a habit's streak to zero. The completion history is kept.")
+	fmt.Println()
+	fmt.Println("  log <habit-name>, history <habit-name>")
+	fmt.Println("      Show every completion of a habit, newest first, with amounts and notes.")
 	fmt.Println()
-	fmt.Println("  stats, statistics")
+	fmt.Println("  history --git [habit-name], log --git [habit-name]")
+	fmt.Println("      List the git commits of the data file when habits are kept in git, newest")
+	fmt.Println("      first, optionally only those about one habit.")
+	fmt.Println()
+	fmt.Println("  revert <commit>")
+	fmt.Println("      Restore the habits as they were after a git commit, as a new commit.")
+	fmt.Println()
+	fmt.Println("  events [habit-name]")
+	fmt.Println("      Show when habits were created, marked, unmarked, renamed, reset or deleted.")
+	fmt.Println("      Requires the event log storage backend (HABIT_STORAGE=events).")
+	fmt.Println()
+	fmt.Println("  stats [--tag <tag>], statistics")
 	fmt.Println("      Display statistics about all your habits (total, streaks, completion rate).")
+	fmt.Println("      With --tag, only habits with that tag are counted.")
 	fmt.Println()
 	fmt.Println("ADVANCED COMMANDS:")
-	fmt.Println("  search <query>, find <query>")
+	fmt.Println("  search <query> [--notes] [--tag <tag>], find <query>")
 	fmt.Println("      Search for habits by name (case-insensitive substring match).")
+	fmt.Println("      With --notes, also search the notes attached to completions.")
+	fmt.Println("      With --tag, only habits with that tag are searched.")
 	fmt.Println()
 	fmt.Println("  edit <current-name> <new-name>, rename <current-name> <new-name>")
 	fmt.Println("      Rename an existing habit.")
 	fmt.Println()
-	fmt.Println("  export <format> <output-file>")
+	fmt.Println("  avoid <habit-name> [--since YYYY-MM-DD], quit <habit-name>")
+	fmt.Println("      Track a bad habit you are quitting. Marking it records a relapse, and its")
+	fmt.Println("      streak is the number of clean days since the last one.")
+	fmt.Println()
+	fmt.Println("  schedule <habit-name> <schedule>")
+	fmt.Println("      Set how often a habit is due. Streaks follow the schedule, so skipping an")
+	fmt.Println("      unscheduled day does not break them. Schedules: daily, weekdays, weekends,")
+	fmt.Println("      a day list (mon,wed,fri), every:N (every N days), N/week, N/month.")
+	fmt.Println()
+	fmt.Println("  target <habit-name> <amount> [unit]")
+	fmt.Println("      Make a habit measurable with a daily target, e.g. habit target Water 8 glasses.")
+	fmt.Println("      Use an amount of 0 to turn it back into a yes/no habit.")
+	fmt.Println()
+	fmt.Println("  tag add <habit-name> <tag>, tag remove <habit-name> <tag>, tag list")
+	fmt.Println("      Group habits with tags such as health or work. Tags are lowercase single")
+	fmt.Println("      words; list, stats, export and search accept --tag to filter by one.")
+	fmt.Println()
+	fmt.Println("  export <format> <output-file> [--tag <tag>]")
 	fmt.Println("      Export habits to a file. Supported formats: csv, json")
 	fmt.Println()
 	fmt.Println("  import <format> <input-file> [--merge]")
-	fmt.Println("      Import habits from a file. Use --merge to merge with existing habits.")
+	fmt.Println("      Import habits from a file. Use --merge to merge with existing habits,")
+	fmt.Println("      matching them by ID first and then by name.")
 	fmt.Println("      Without --merge, existing habits will be replaced. Supported formats: csv, json")
 	fmt.Println()
+	fmt.Println("  merge <base> <ours> <theirs> [--output <file>]")
+	fmt.Println("      Merge two data files changed separately from a common base, such as")
+	fmt.Println("      conflicting copies made by a file sync tool. Habits are matched by ID and")
+	fmt.Println("      completions by day; where both sides changed the same thing, ours is kept")
+	fmt.Println("      and the conflict is listed. The result is written over ours unless --output")
+	fmt.Println("      is given. Works as a git merge driver: habit merge %O %A %B")
+	fmt.Println()
+	fmt.Println("  sync [server-url]")
+	fmt.Println("      Sync habits with a habit sync-server: changes made elsewhere are merged")
+	fmt.Println("      in like merge does, and local changes are sent. The URL defaults to")
+	fmt.Println("      HABIT_SYNC_URL.")
+	fmt.Println()
+	fmt.Println("  sync-server [--addr <host:port>] [--insecure]")
+	fmt.Printf("      Serve the habits to sync clients over HTTP (default address %s) until\n", defaultSyncAddr)
+	fmt.Println("      stopped. Set HABIT_SYNC_TOKEN on the server and clients to require a token;")
+	fmt.Println("      addresses other machines can reach, e.g. --addr :8765, need one unless")
+	fmt.Println("      --insecure is given.")
+	fmt.Println()
 	fmt.Println("  backup [output-file]")
 	fmt.Println("      Create a backup of your habits data. If no file specified, uses timestamp.")
 	fmt.Println()
 	fmt.Println("  restore <backup-file>")
 	fmt.Println("      Restore habits from a backup file. Current data is auto-backed up first.")
 	fmt.Println()
+	fmt.Println("  encrypt, decrypt")
+	fmt.Println("      Encrypt the habits with a passphrase, or store them unencrypted again.")
+	fmt.Println("      Encrypted habits are decrypted transparently by every command.")
+	fmt.Println()
+	fmt.Printf("  migrate-storage <%s> [target-file] [--force]\n", strings.Join(backendNames(), "|"))
+	fmt.Println("      Copy all habits to another storage backend. The target defaults to the data")
+	fmt.Println("      file with a .db (SQLite), .jsonl (event log) or .json extension; the current")
+	fmt.Println("      data is not changed.")
+	fmt.Println("      --force overwrites a target that already has habits.")
+	fmt.Println()
 	fmt.Println("OTHER COMMANDS:")
 	fmt.Println("  version, -v, --version")
 	fmt.Println("      Display the version number.")
@@ -203,14 +759,27 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  # Basic usage")
+	fmt.Println("  habit add \"Morning Exercise\" --description \"20 minutes\" --color green")
 	fmt.Println("  habit mark \"Morning Exercise\"")
+	fmt.Println("  habit mark Reading --yesterday")
+	fmt.Println("  habit mark Run --note \"5k in 27min\"")
 	fmt.Println("  habit list")
 	fmt.Println("  habit stats")
+	fmt.Println("  habit pause Running --until 2025-02-01")
+	fmt.Println("  habit vacation start --until 2025-08-15")
+	fmt.Println("  habit freeze Reading --yesterday")
+	fmt.Println("  habit archive \"Old Habit\"")
 	fmt.Println("  habit delete \"Old Habit\"")
 	fmt.Println()
 	fmt.Println("  # Advanced usage")
 	fmt.Println("  habit search exercise")
 	fmt.Println("  habit edit \"Excercise\" \"Exercise\"")
+	fmt.Println("  habit schedule Gym 3/week")
+	fmt.Println("  habit avoid Smoking --since 2025-01-01")
+	fmt.Println("  habit target Water 8 glasses")
+	fmt.Println("  habit mark Water 3")
+	fmt.Println("  habit tag add Running health")
+	fmt.Println("  habit list --tag health")
 	fmt.Println("  habit export csv habits.csv")
 	fmt.Println("  habit import json habits-backup.json --merge")
 	fmt.Println("  habit backup")
@@ -223,4 +792,33 @@ func printHelp() {
 	fmt.Println("  Example:")
 	fmt.Println("    export HABIT_DATA_FILE=~/my-habits.json")
 	fmt.Println()
+	fmt.Println("  HABIT_DAY_START sets the hour (0-23) at which a new day starts, so habits marked")
+	fmt.Println("  after midnight count for the previous day. Default: 0")
+	fmt.Println("  HABIT_TIMEZONE sets the time zone days are counted in, e.g. Europe/Berlin.")
+	fmt.Println("  Default: the system time zone")
+	fmt.Println()
+	fmt.Println("  HABIT_NOW runs a command as if it were the given time, for scripts and")
+	fmt.Println("  replaying old data, e.g. HABIT_NOW=2025-01-15 habit mark Reading.")
+	fmt.Println()
+	fmt.Println("  HABIT_DATA_URL selects the storage backend by URL scheme and overrides")
+	fmt.Println("  HABIT_DATA_FILE, e.g. HABIT_DATA_URL=sqlite://~/habits.db. HABIT_STORAGE uses a")
+	fmt.Println("  backend for the default data file instead. Available backends:")
+	for _, b := range storage.Backends() {
+		fmt.Printf("    %-10s %s\n", b.Name+"://", b.Description)
+	}
+	fmt.Println()
+	fmt.Println("  Settings can also be kept in ~/.habit-tracker/config (or the file HABIT_CONFIG")
+	fmt.Println("  names) as lines such as: data_url = sqlite://~/habits.db. Each setting is named")
+	fmt.Println("  after its variable without HABIT_; environment variables take precedence.")
+	fmt.Println()
+	fmt.Println("  HABIT_LOCK_TIMEOUT sets how long a command waits for another habit process")
+	fmt.Println("  to release the data file, e.g. 30s. Default: 5s")
+	fmt.Println()
+	fmt.Println("  HABIT_PASSPHRASE or HABIT_KEYFILE (a file holding it) give the passphrase of")
+	fmt.Println("  encrypted habits. Without either, habit asks for it on the terminal.")
+	fmt.Println()
+	fmt.Println("  HABIT_SYNC_URL is the server habit sync uses by default, e.g.")
+	fmt.Println("  http://nas.local:8765. HABIT_SYNC_TOKEN is the token sync-server requires and")
+	fmt.Println("  sync sends.")
+	fmt.Println()
 }
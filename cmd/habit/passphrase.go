@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/internal/config"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+	"golang.org/x/term"
+)
+
+// passphraseSource returns where the passphrase for encrypted habits comes
+// from: HABIT_PASSPHRASE, then the key file, then a prompt on the terminal,
+// which asks twice if confirm is set. The passphrase is only obtained once.
+func passphraseSource(cfg *config.Config, confirm bool) storage.PassphraseFunc {
+	var passphrase []byte
+	return func() ([]byte, error) {
+		if passphrase != nil {
+			return passphrase, nil
+		}
+
+		var err error
+		switch {
+		case cfg.Passphrase != "":
+			passphrase = []byte(cfg.Passphrase)
+		case cfg.KeyFile != "":
+			passphrase, err = readKeyFile(cfg.KeyFile)
+		default:
+			passphrase, err = promptPassphrase(confirm)
+		}
+		if err != nil {
+			passphrase = nil
+		}
+		return passphrase, err
+	}
+}
+
+// readKeyFile reads a passphrase from a file, ignoring a trailing newline.
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return bytes.TrimRight(data, "\r\n"), nil
+}
+
+// promptPassphrase asks for the passphrase without echoing it.
+func promptPassphrase(confirm bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("habits are encrypted: set HABIT_PASSPHRASE or HABIT_KEYFILE, or run habit in a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if !bytes.Equal(passphrase, again) {
+		return nil, errors.New("the passphrases do not match")
+	}
+	return passphrase, nil
+}
@@ -8,7 +8,7 @@ _habit_completions() {
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
     # All available commands
-    local commands="list ls mark done delete del rm reset stats statistics search find edit rename export import backup restore version help"
+    local commands="list ls add new mark done unmark undo delete del rm archive unarchive pause resume vacation freeze reset log history events stats statistics search find edit rename avoid quit schedule target tag export import merge sync sync-server backup restore revert encrypt decrypt migrate-storage version help"
 
     # Command-specific completions
     case "${prev}" in
@@ -16,6 +16,26 @@ _habit_completions() {
             COMPREPLY=( $(compgen -W "${commands}" -- ${cur}) )
             return 0
             ;;
+        vacation)
+            COMPREPLY=( $(compgen -W "start end" -- ${cur}) )
+            return 0
+            ;;
+        tag)
+            COMPREPLY=( $(compgen -W "add remove list" -- ${cur}) )
+            return 0
+            ;;
+        log|history)
+            COMPREPLY=( $(compgen -W "--git" -- ${cur}) )
+            return 0
+            ;;
+        sync-server)
+            COMPREPLY=( $(compgen -W "--addr --insecure" -- ${cur}) )
+            return 0
+            ;;
+        migrate-storage)
+            COMPREPLY=( $(compgen -W "file json sqlite events git webdav webdavs mem" -- ${cur}) )
+            return 0
+            ;;
         export)
             COMPREPLY=( $(compgen -W "csv json" -- ${cur}) )
             return 0
@@ -29,7 +49,7 @@ _habit_completions() {
             COMPREPLY=( $(compgen -f -- ${cur}) )
             return 0
             ;;
-        backup|restore)
+        backup|restore|merge)
             # Offer file completion
             COMPREPLY=( $(compgen -f -- ${cur}) )
             return 0
@@ -3,12 +3,25 @@
 # Core commands
 complete -c habit -f -n "__fish_use_subcommand" -a "list" -d "List all habits with their streaks"
 complete -c habit -f -n "__fish_use_subcommand" -a "ls" -d "List all habits with their streaks"
+complete -c habit -f -n "__fish_use_subcommand" -a "add" -d "Start tracking a new habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "new" -d "Start tracking a new habit"
 complete -c habit -f -n "__fish_use_subcommand" -a "mark" -d "Mark a habit as done for today"
 complete -c habit -f -n "__fish_use_subcommand" -a "done" -d "Mark a habit as done for today"
+complete -c habit -f -n "__fish_use_subcommand" -a "unmark" -d "Remove a completion from a habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "undo" -d "Remove a completion from a habit"
 complete -c habit -f -n "__fish_use_subcommand" -a "delete" -d "Delete a habit"
 complete -c habit -f -n "__fish_use_subcommand" -a "del" -d "Delete a habit"
 complete -c habit -f -n "__fish_use_subcommand" -a "rm" -d "Delete a habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "archive" -d "Hide a habit but keep its history"
+complete -c habit -f -n "__fish_use_subcommand" -a "unarchive" -d "Restore an archived habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "pause" -d "Pause a habit without breaking its streak"
+complete -c habit -f -n "__fish_use_subcommand" -a "resume" -d "Resume a paused habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "vacation" -d "Put all habits on vacation"
+complete -c habit -f -n "__fish_use_subcommand" -a "freeze" -d "Use a freeze token to cover a missed day"
 complete -c habit -f -n "__fish_use_subcommand" -a "reset" -d "Reset a habit's streak"
+complete -c habit -f -n "__fish_use_subcommand" -a "log" -d "Show a habit's history with notes"
+complete -c habit -f -n "__fish_use_subcommand" -a "history" -d "Show a habit's history with notes"
+complete -c habit -f -n "__fish_use_subcommand" -a "events" -d "Show recorded changes to habits"
 complete -c habit -f -n "__fish_use_subcommand" -a "stats" -d "Show habit statistics"
 complete -c habit -f -n "__fish_use_subcommand" -a "statistics" -d "Show habit statistics"
 
@@ -17,10 +30,22 @@ complete -c habit -f -n "__fish_use_subcommand" -a "search" -d "Search for habit
 complete -c habit -f -n "__fish_use_subcommand" -a "find" -d "Search for habits by name"
 complete -c habit -f -n "__fish_use_subcommand" -a "edit" -d "Rename a habit"
 complete -c habit -f -n "__fish_use_subcommand" -a "rename" -d "Rename a habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "avoid" -d "Track a habit you are quitting"
+complete -c habit -f -n "__fish_use_subcommand" -a "quit" -d "Track a habit you are quitting"
+complete -c habit -f -n "__fish_use_subcommand" -a "schedule" -d "Set how often a habit is due"
+complete -c habit -f -n "__fish_use_subcommand" -a "target" -d "Set a daily target for a measurable habit"
+complete -c habit -f -n "__fish_use_subcommand" -a "tag" -d "Add, remove or list habit tags"
 complete -c habit -f -n "__fish_use_subcommand" -a "export" -d "Export habits to a file"
 complete -c habit -f -n "__fish_use_subcommand" -a "import" -d "Import habits from a file"
+complete -c habit -f -n "__fish_use_subcommand" -a "merge" -d "Merge two changed copies of a data file"
+complete -c habit -f -n "__fish_use_subcommand" -a "sync" -d "Sync habits with a sync server"
+complete -c habit -f -n "__fish_use_subcommand" -a "sync-server" -d "Serve habits to sync clients"
 complete -c habit -f -n "__fish_use_subcommand" -a "backup" -d "Create a backup of habits data"
 complete -c habit -f -n "__fish_use_subcommand" -a "restore" -d "Restore from a backup file"
+complete -c habit -f -n "__fish_use_subcommand" -a "revert" -d "Go back to a git commit of the data file"
+complete -c habit -f -n "__fish_use_subcommand" -a "encrypt" -d "Encrypt habits with a passphrase"
+complete -c habit -f -n "__fish_use_subcommand" -a "decrypt" -d "Store encrypted habits unencrypted again"
+complete -c habit -f -n "__fish_use_subcommand" -a "migrate-storage" -d "Copy habits to another storage backend"
 
 # Other commands
 complete -c habit -f -n "__fish_use_subcommand" -a "version" -d "Show version information"
@@ -34,5 +59,64 @@ complete -c habit -f -n "__fish_seen_subcommand_from export" -a "json" -d "Expor
 complete -c habit -f -n "__fish_seen_subcommand_from import" -a "csv" -d "Import from CSV"
 complete -c habit -f -n "__fish_seen_subcommand_from import" -a "json" -d "Import from JSON"
 
+# Tag actions
+complete -c habit -f -n "__fish_seen_subcommand_from tag" -a "add" -d "Add a tag to a habit"
+complete -c habit -f -n "__fish_seen_subcommand_from tag" -a "remove" -d "Remove a tag from a habit"
+complete -c habit -f -n "__fish_seen_subcommand_from tag" -a "list" -d "List tags in use"
+
 # Import merge flag
 complete -c habit -f -n "__fish_seen_subcommand_from import" -l merge -d "Merge with existing habits"
+
+# Date options for mark and unmark
+complete -c habit -f -n "__fish_seen_subcommand_from mark done unmark undo" -l date -d "Use a past date (YYYY-MM-DD)"
+complete -c habit -f -n "__fish_seen_subcommand_from mark done unmark undo" -l yesterday -d "Use yesterday's date"
+complete -c habit -f -n "__fish_seen_subcommand_from mark done" -l note -d "Attach a note to the completion"
+complete -c habit -f -n "__fish_seen_subcommand_from search find" -l notes -d "Also search completion notes"
+complete -c habit -f -n "__fish_seen_subcommand_from mark done" -l amount -d "Amount to log for a measurable habit"
+complete -c habit -f -n "__fish_seen_subcommand_from mark done" -l create -d "Create the habit if it does not exist"
+
+# Merge
+complete -c habit -F -n "__fish_seen_subcommand_from merge"
+complete -c habit -r -n "__fish_seen_subcommand_from merge" -l output -d "Write the result to another file"
+
+# Sync server
+complete -c habit -x -n "__fish_seen_subcommand_from sync-server" -l addr -d "Address to listen on"
+complete -c habit -f -n "__fish_seen_subcommand_from sync-server" -l insecure -d "Serve other machines without a token"
+
+# Git history
+complete -c habit -f -n "__fish_seen_subcommand_from log history" -l git -d "List git commits of the data file"
+
+# Tag filter
+complete -c habit -f -n "__fish_seen_subcommand_from list ls stats statistics export search find" -l tag -d "Only include habits with this tag"
+
+# Options for add
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l description -d "Description of the habit"
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l schedule -d "How often the habit is due"
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l start -d "Date tracking starts (YYYY-MM-DD)"
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l color -a "red green yellow blue purple cyan gray white" -d "Color in list"
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l target -d "Daily target amount"
+complete -c habit -f -n "__fish_seen_subcommand_from add new" -l unit -d "Unit of the target"
+
+# Options for list and pause
+complete -c habit -f -n "__fish_seen_subcommand_from list ls" -l archived -d "Show archived habits"
+complete -c habit -f -n "__fish_seen_subcommand_from pause" -l until -d "Last paused day (YYYY-MM-DD)"
+
+# Vacation and freeze
+complete -c habit -f -n "__fish_seen_subcommand_from vacation" -a "start" -d "Start a vacation"
+complete -c habit -f -n "__fish_seen_subcommand_from vacation" -a "end" -d "End the vacation"
+complete -c habit -f -n "__fish_seen_subcommand_from vacation" -l from -d "First vacation day (YYYY-MM-DD)"
+complete -c habit -f -n "__fish_seen_subcommand_from vacation" -l until -d "Last vacation day (YYYY-MM-DD)"
+complete -c habit -f -n "__fish_seen_subcommand_from freeze" -l add -d "Give the habit more freeze tokens"
+complete -c habit -f -n "__fish_seen_subcommand_from freeze" -l date -d "Day to cover (YYYY-MM-DD)"
+complete -c habit -f -n "__fish_seen_subcommand_from freeze" -l yesterday -d "Cover yesterday"
+
+# Storage migration
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "file" -d "JSON file"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "json" -d "JSON file"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "sqlite" -d "SQLite database"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "events" -d "Event log"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "git" -d "JSON file committed to git"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "webdav" -d "JSON file on a WebDAV server"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "webdavs" -d "JSON file on a WebDAV server over HTTPS"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -a "mem" -d "In memory, not saved"
+complete -c habit -f -n "__fish_seen_subcommand_from migrate-storage" -l force -d "Overwrite a target that has habits"
@@ -75,3 +75,22 @@ func Dim(text string) string {
 func Sprintf(colorCode, format string, a ...interface{}) string {
 	return Colorize(fmt.Sprintf(format, a...), colorCode)
 }
+
+// byName maps color names to their ANSI codes.
+var byName = map[string]string{
+	"red":    Red,
+	"green":  Green,
+	"yellow": Yellow,
+	"blue":   Blue,
+	"purple": Purple,
+	"cyan":   Cyan,
+	"gray":   Gray,
+	"white":  White,
+}
+
+// ByName returns the ANSI code for a color name such as "green", and false
+// if the name is unknown.
+func ByName(name string) (string, bool) {
+	code, ok := byName[name]
+	return code, ok
+}
@@ -2,19 +2,41 @@
 package config
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
 // Config holds application configuration.
 type Config struct {
-	DataFilePath string
+	DataURL      string        // Where habits are stored: a JSON file path or a URL such as sqlite://path
+	DayStartHour int           // Hour (0-23) at which a new day starts for marking habits
+	Timezone     string        // IANA time zone name days are counted in; empty for local time
+	Now          time.Time     // Fixed current time for scripting and replays; zero for the system clock
+	LockTimeout  time.Duration // How long to wait for another habit process; zero for the storage default
+	Passphrase   string        // Passphrase for encrypted habits; only read from the environment
+	KeyFile      string        // File holding the passphrase for encrypted habits
+	SyncURL      string        // Sync server `habit sync` uses when none is given
+	SyncToken    string        // Token for the sync server, both when serving and syncing
 }
 
+// fileKeys are the settings a config file may contain, each named after its
+// environment variable without the HABIT_ prefix, in lowercase.
+var fileKeys = []string{"data_url", "data_file", "storage", "day_start", "timezone", "now", "lock_timeout", "keyfile", "sync_url", "sync_token"}
+
 // Default returns the default configuration.
 func Default() *Config {
 	return &Config{
-		DataFilePath: getDefaultDataFilePath(),
+		DataURL: getDefaultDataFilePath(),
 	}
 }
 
@@ -33,23 +55,201 @@ func getDefaultDataFilePath() string {
 	return filepath.Join(configDir, "habits.json")
 }
 
+// getConfigFilePath returns the path of the config file: HABIT_CONFIG if
+// set, otherwise config next to the default data file.
+func getConfigFilePath() string {
+	if path := os.Getenv("HABIT_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(getDefaultDataFilePath()), "config")
+}
+
 // New creates a new configuration with optional overrides.
-func New(dataFilePath string) *Config {
+func New(dataURL string) *Config {
 	cfg := Default()
-	if dataFilePath != "" {
-		cfg.DataFilePath = dataFilePath
+	if dataURL != "" {
+		cfg.DataURL = dataURL
 	}
 	return cfg
 }
 
-// FromEnv creates configuration from environment variables.
-func FromEnv() *Config {
+// FromEnv creates configuration from environment variables and the config
+// file. Environment variables take precedence over the file.
+func FromEnv() (*Config, error) {
 	cfg := Default()
 
-	// Override with environment variable if set
-	if path := os.Getenv("HABIT_DATA_FILE"); path != "" {
-		cfg.DataFilePath = path
+	file, err := readConfigFile(getConfigFilePath())
+	if err != nil {
+		return nil, err
+	}
+	getenv := func(name string) string {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return file[strings.ToLower(strings.TrimPrefix(name, "HABIT_"))]
 	}
 
-	return cfg
+	// The data location is taken from the environment or the file as a whole,
+	// so that e.g. HABIT_STORAGE is not overridden by the file's data_url
+	location := []string{os.Getenv("HABIT_DATA_URL"), os.Getenv("HABIT_DATA_FILE"), os.Getenv("HABIT_STORAGE")}
+	if strings.Join(location, "") == "" {
+		location = []string{file["data_url"], file["data_file"], file["storage"]}
+	}
+	if err := cfg.setDataURL(location[0], location[1], location[2]); err != nil {
+		return nil, err
+	}
+
+	if value := getenv("HABIT_DAY_START"); value != "" {
+		hour, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hour < 0 || hour > 23 {
+			return nil, fmt.Errorf("invalid HABIT_DAY_START '%s' (expected an hour from 0 to 23)", value)
+		}
+		cfg.DayStartHour = hour
+	}
+
+	if value := strings.TrimSpace(getenv("HABIT_LOCK_TIMEOUT")); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid HABIT_LOCK_TIMEOUT '%s' (expected a duration such as 10s)", value)
+		}
+		cfg.LockTimeout = timeout
+	}
+
+	// A passphrase in the config file would defeat encrypting the data
+	cfg.Passphrase = os.Getenv("HABIT_PASSPHRASE")
+	cfg.KeyFile = getenv("HABIT_KEYFILE")
+
+	cfg.SyncURL = strings.TrimSpace(getenv("HABIT_SYNC_URL"))
+	cfg.SyncToken = strings.TrimSpace(getenv("HABIT_SYNC_TOKEN"))
+
+	if tz := strings.TrimSpace(getenv("HABIT_TIMEZONE")); tz != "" {
+		cfg.Timezone = tz
+		if _, err := cfg.Location(); err != nil {
+			return nil, err
+		}
+	}
+
+	if value := strings.TrimSpace(getenv("HABIT_NOW")); value != "" {
+		loc, err := cfg.Location()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Now, err = parseNow(value, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// setDataURL works out where habits are stored. A data URL wins over a data
+// file; without either, the default data file is used in the given backend,
+// with that backend's extension.
+func (c *Config) setDataURL(dataURL, dataFile, backendName string) error {
+	if dataURL != "" {
+		c.DataURL = dataURL
+		return nil
+	}
+
+	backendName = strings.TrimSpace(backendName)
+	if backendName == "" {
+		if dataFile != "" {
+			c.DataURL = dataFile
+		}
+		return nil
+	}
+
+	backend, err := storage.Lookup(backendName)
+	if err != nil {
+		return fmt.Errorf("invalid HABIT_STORAGE: %w", err)
+	}
+	location := dataFile
+	switch {
+	case strings.Contains(dataFile, "://"):
+		// The file names its own backend
+		c.DataURL = dataFile
+		return nil
+	case dataFile == "" && backend.Extension != "":
+		location = strings.TrimSuffix(c.DataURL, filepath.Ext(c.DataURL)) + backend.Extension
+	}
+	c.DataURL = storage.FormatURL(backend.Name, location)
+	return nil
+}
+
+// readConfigFile reads "key = value" settings from a config file. Blank lines
+// and lines starting with # are ignored, and a missing file has no settings.
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid line %d in %s (expected key = value)", line, path)
+		}
+		if !slices.Contains(fileKeys, key) {
+			return nil, fmt.Errorf("unknown setting '%s' on line %d of %s (expected one of %s)",
+				key, line, path, strings.Join(fileKeys, ", "))
+		}
+		settings[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return settings, nil
+}
+
+// parseNow parses a HABIT_NOW value: an RFC 3339 timestamp, a local
+// "YYYY-MM-DDTHH:MM" time, or a date, which is read as noon on that day so
+// that it falls on the same day whatever the day start hour.
+func parseNow(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t.Add(12 * time.Hour), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid HABIT_NOW '%s' (expected YYYY-MM-DD, YYYY-MM-DDTHH:MM or RFC 3339)", value)
+}
+
+// SyncStatePath returns the file in which `habit sync` keeps the last synced
+// version of the habits at DataURL, next to the default data file. Storage
+// that is not saved has none.
+func (c *Config) SyncStatePath() string {
+	if backend, _ := storage.ParseURL(c.DataURL); backend == storage.BackendMemory {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.DataURL))
+	return filepath.Join(filepath.Dir(getDefaultDataFilePath()), "sync", hex.EncodeToString(sum[:8])+".json")
+}
+
+// Location returns the time zone days are counted in. An empty Timezone
+// means the system's local time zone.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", c.Timezone, err)
+	}
+	return loc, nil
 }
@@ -0,0 +1,120 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// AddOptions holds optional settings for the add command.
+type AddOptions struct {
+	Description string  // Free-text description
+	Schedule    string  // Schedule specification, see models.ParseSchedule (default daily)
+	StartDate   string  // Date tracking starts: "today", "yesterday", "tomorrow" or YYYY-MM-DD (default today)
+	Color       string  // Display color, one of models.Colors
+	Target      float64 // Daily target for measurable habits (0 for yes/no habits)
+	Unit        string  // Unit of the target
+}
+
+// Add creates a new habit without marking it as done.
+func Add(store storage.Storage, clock Clock, habitName string, opts AddOptions) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+	if opts.Unit != "" && opts.Target == 0 {
+		return fmt.Errorf("a unit needs a target, e.g. --target 8 --unit glasses")
+	}
+
+	today := clock.Today()
+	startDate, err := models.ParseDate(opts.StartDate, today)
+	if err != nil {
+		return err
+	}
+	schedule, err := models.ParseSchedule(opts.Schedule)
+	if err != nil {
+		return err
+	}
+
+	habit := models.Habit{
+		Name:        habitName,
+		Schedule:    schedule,
+		StartDate:   startDate,
+		Description: strings.TrimSpace(opts.Description),
+		Color:       strings.ToLower(strings.TrimSpace(opts.Color)),
+	}
+	if err := habit.SetTarget(opts.Target, opts.Unit); err != nil {
+		return err
+	}
+
+	err = store.Update(func(habits *models.HabitList) error {
+		if habits.Contains(habitName) {
+			return fmt.Errorf("habit '%s' already exists", habitName)
+		}
+		if err := habits.Add(habit); err != nil {
+			return fmt.Errorf("invalid habit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	details := []string{habit.Schedule.String()}
+	if habit.IsQuantitative() {
+		details = append(details, habit.TargetString())
+	}
+	fmt.Printf("✓ Added habit '%s' (%s)\n", habitName, strings.Join(details, ", "))
+	if startDate > today.Format(models.DateFormat) {
+		fmt.Printf("  Tracking starts on %s.\n", startDate)
+	}
+	return nil
+}
+
+// findHabit resolves a habit by ID, name or unique ID prefix, returning the
+// usual "not found" error with a suggestion when nothing matches.
+func findHabit(habits models.HabitList, ref string) (*models.Habit, int, error) {
+	habit, index, err := habits.Resolve(ref)
+	if errors.Is(err, models.ErrHabitNotFound) {
+		return nil, -1, notFoundError(habits, ref)
+	}
+	return habit, index, err
+}
+
+// notFoundError returns the error for a habit name that does not exist,
+// suggesting the closest existing name if there is one.
+func notFoundError(habits models.HabitList, habitName string) error {
+	if suggestion := habits.Suggest(habitName); suggestion != "" {
+		return fmt.Errorf("habit '%s' not found. Did you mean '%s'?", habitName, suggestion)
+	}
+	return fmt.Errorf("habit '%s' not found", habitName)
+}
+
+// updateHabit finds a habit by ID, name or unique ID prefix and lets fn change
+// it, holding the data file lock from load to save so that concurrent habit
+// processes cannot lose each other's changes. Nothing is saved if fn fails.
+// Commands that change the habit list as a whole call store.Update directly,
+// which locks the same way.
+func updateHabit(store storage.Storage, ref string, fn func(habit *models.Habit) error) (*models.Habit, error) {
+	var updated *models.Habit
+	err := store.Update(func(habits *models.HabitList) error {
+		habit, _, err := findHabit(*habits, ref)
+		if err != nil {
+			return err
+		}
+		if err := fn(habit); err != nil {
+			return err
+		}
+		updated = habit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
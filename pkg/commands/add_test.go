@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestAdd(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	opts := AddOptions{
+		Description: "Drink enough water",
+		Schedule:    "weekdays",
+		StartDate:   "2025-01-06",
+		Color:       "Blue",
+		Target:      8,
+		Unit:        "glasses",
+	}
+	if err := Add(store, Clock{}, "Water", opts); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load habits: %v", err)
+	}
+	water, _ := loaded.Find("Water")
+	if water == nil {
+		t.Fatal("Water habit not found")
+	}
+	if len(water.History) != 0 {
+		t.Errorf("Expected no completions, got %d", len(water.History))
+	}
+	if water.Description != "Drink enough water" || water.Color != "blue" || water.StartDate != "2025-01-06" {
+		t.Errorf("Unexpected metadata: %+v", water)
+	}
+	if water.Schedule.Kind != models.ScheduleWeekdays || water.Target != 8 || water.Unit != "glasses" {
+		t.Errorf("Unexpected schedule or target: %+v", water)
+	}
+
+	tests := []struct {
+		name      string
+		habitName string
+		opts      AddOptions
+	}{
+		{"duplicate name", "water", AddOptions{}},
+		{"empty name", "  ", AddOptions{}},
+		{"unknown color", "Reading", AddOptions{Color: "pink"}},
+		{"invalid schedule", "Reading", AddOptions{Schedule: "sometimes"}},
+		{"unit without target", "Reading", AddOptions{Unit: "pages"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Add(store, Clock{}, tt.habitName, tt.opts); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestMark_UnknownHabit(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	err := Mark(store, Clock{}, "Excercise", MarkOptions{})
+	if err == nil {
+		t.Fatal("Expected error for unknown habit, got nil")
+	}
+	if !strings.Contains(err.Error(), "Did you mean 'Exercise'?") {
+		t.Errorf("Expected a suggestion, got: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	if len(loaded) != 1 {
+		t.Fatalf("Expected no habit to be created, got %d habits", len(loaded))
+	}
+
+	if err := Mark(store, Clock{}, "Meditation", MarkOptions{Create: true}); err != nil {
+		t.Fatalf("Mark with Create failed: %v", err)
+	}
+	loaded, _ = store.Load()
+	if meditation, _ := loaded.Find("Meditation"); meditation == nil || !meditation.IsMarkedToday(time.Now()) {
+		t.Error("Expected Meditation to be created and marked today")
+	}
+}
@@ -0,0 +1,50 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Archive hides a habit from list and stats while keeping its history.
+func Archive(store storage.Storage, habitName string) error {
+	return setArchived(store, habitName, true)
+}
+
+// Unarchive brings an archived habit back into list and stats.
+func Unarchive(store storage.Storage, habitName string) error {
+	return setArchived(store, habitName, false)
+}
+
+func setArchived(store storage.Storage, habitName string, archived bool) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		if habit.Archived == archived {
+			if archived {
+				return fmt.Errorf("habit '%s' is already archived", habit.Name)
+			}
+			return fmt.Errorf("habit '%s' is not archived", habit.Name)
+		}
+		habit.Archived = archived
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	if archived {
+		fmt.Printf("✓ Archived '%s'. Its history is kept; restore it with: habit unarchive %s\n", habitName, habitName)
+	} else {
+		fmt.Printf("✓ Unarchived '%s'\n", habitName)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Exercise", History: []models.Completion{{Date: "2025-01-10"}}},
+		{Name: "Reading"},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := Archive(store, "exercise"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if err := Archive(store, "Exercise"); err == nil {
+		t.Error("Expected error when archiving twice")
+	}
+	if err := Mark(store, Clock{}, "Exercise", MarkOptions{}); err == nil {
+		t.Error("Expected error when marking an archived habit")
+	}
+
+	loaded, _ := store.Load()
+	if len(loaded.Active()) != 1 || len(loaded.Archived()) != 1 {
+		t.Fatalf("Expected 1 active and 1 archived habit, got %d and %d", len(loaded.Active()), len(loaded.Archived()))
+	}
+	exercise, _ := loaded.Find("Exercise")
+	if len(exercise.History) != 1 {
+		t.Error("Expected history to be kept when archiving")
+	}
+
+	if err := List(store, Clock{}, ListOptions{Archived: true}); err != nil {
+		t.Errorf("List archived failed: %v", err)
+	}
+
+	if err := Unarchive(store, "Exercise"); err != nil {
+		t.Fatalf("Unarchive failed: %v", err)
+	}
+	if err := Unarchive(store, "Exercise"); err == nil {
+		t.Error("Expected error when unarchiving an active habit")
+	}
+}
+
+func TestPause(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	today := time.Now()
+	if err := store.Save(models.HabitList{{Name: "Running"}}); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	yesterday := today.AddDate(0, 0, -1).Format(models.DateFormat)
+	if err := Pause(store, Clock{}, "Running", yesterday); err == nil {
+		t.Error("Expected error for pause ending in the past")
+	}
+
+	until := today.AddDate(0, 0, 7).Format(models.DateFormat)
+	if err := Pause(store, Clock{}, "Running", until); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	running, _ := loaded.Find("Running")
+	if got, paused := running.PausedUntil(today.Format(models.DateFormat)); !paused || got != until {
+		t.Errorf("Expected Running to be paused until %s, got %q, %v", until, got, paused)
+	}
+
+	if err := Resume(store, Clock{}, "Running"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	loaded, _ = store.Load()
+	running, _ = loaded.Find("Running")
+	if !running.IsDue(today) {
+		t.Error("Expected Running to be due after resuming")
+	}
+}
@@ -0,0 +1,59 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Avoid starts tracking a habit being quit. Marking it records a relapse,
+// and its streak is the number of days since the last one. The clean streak
+// counts from since ("today", "yesterday" or YYYY-MM-DD; default today).
+func Avoid(store storage.Storage, clock Clock, habitName, since string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	today := clock.Today()
+	startDate, err := models.ResolveDate(since, today)
+	if err != nil {
+		return err
+	}
+
+	habit := models.Habit{
+		Name:      habitName,
+		Kind:      models.KindAvoid,
+		StartDate: startDate,
+	}
+
+	err = store.Update(func(habits *models.HabitList) error {
+		if habits.Contains(habitName) {
+			return fmt.Errorf("habit '%s' already exists", habitName)
+		}
+		if err := habits.Add(habit); err != nil {
+			return fmt.Errorf("invalid habit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Now avoiding '%s' (clean for %d day(s)). Use 'habit mark %s' to record a relapse.\n",
+		habitName, habit.CleanDays(today), habitName)
+	return nil
+}
+
+// cleanStatus shows how long an avoid habit has been clean, e.g. " | Clean for 12 day(s)".
+func cleanStatus(h *models.Habit, today time.Time) string {
+	if !h.IsAvoid() {
+		return ""
+	}
+	return fmt.Sprintf(" | Clean for %d day(s)", h.CleanDays(today))
+}
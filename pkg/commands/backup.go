@@ -10,21 +10,21 @@ import (
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Backup creates a backup of the habits data file.
-func Backup(store storage.Storage, backupPath string) error {
+// Backup creates a backup of the habits data file, vacations included.
+func Backup(store storage.Storage, clock Clock, backupPath string) error {
 	// Load habits to ensure file is valid
-	habits, err := store.Load()
+	current, err := store.LoadData()
 	if err != nil {
 		return fmt.Errorf("failed to load habits: %w", err)
 	}
 
-	if len(habits) == 0 {
+	if len(current.Habits) == 0 {
 		return fmt.Errorf("no habits to backup")
 	}
 
 	// Generate backup filename if not specified
 	if backupPath == "" {
-		timestamp := time.Now().Format("20060102-150405")
+		timestamp := clock.Now().Format("20060102-150405")
 		backupPath = fmt.Sprintf("habits-backup-%s.json", timestamp)
 	}
 
@@ -34,61 +34,93 @@ func Backup(store storage.Storage, backupPath string) error {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Get source file path
-	sourcePath := store.GetPath()
-
-	// Read source file
-	data, err := os.ReadFile(sourcePath)
+	// Backups are JSON data files whatever the storage backend
+	data, err := encodeData(store, current, clock.Now())
 	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
+		return fmt.Errorf("failed to encode habits: %w", err)
 	}
 
 	// Write backup file
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write backup file: %w", err)
 	}
 
-	fmt.Printf("✓ Backup created: %s (%d habit(s))\n", backupPath, len(habits))
+	fmt.Printf("✓ Backup created: %s (%d habit(s))\n", backupPath, len(current.Habits))
 	return nil
 }
 
-// Restore restores habits from a backup file.
-func Restore(store storage.Storage, backupPath string) error {
+// Restore restores habits and vacations from a backup file.
+func Restore(store storage.Storage, clock Clock, backupPath string) error {
 	// Check if backup file exists
 	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
 		return fmt.Errorf("backup file not found: %s", backupPath)
 	}
 
-	// Create temporary storage to validate the backup
-	tempStorage := storage.NewJSONStorage(backupPath)
-	habits, err := tempStorage.Load()
+	// Parse the backup without touching it, upgrading older formats
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+	backup, err := decodeData(store, data)
 	if err != nil {
 		return fmt.Errorf("invalid backup file: %w", err)
 	}
 
 	// Validate habits
-	for i, habit := range habits {
+	for i, habit := range backup.Habits {
 		if err := habit.Validate(); err != nil {
 			return fmt.Errorf("invalid habit at index %d in backup: %w", i, err)
 		}
 	}
+	if err := backup.Vacations.Validate(); err != nil {
+		return fmt.Errorf("invalid vacation in backup: %w", err)
+	}
 
 	// Create backup of current data before restoring
 	if store.Exists() {
-		timestamp := time.Now().Format("20060102-150405")
+		timestamp := clock.Now().Format("20060102-150405")
 		autoBackupPath := fmt.Sprintf("habits-auto-backup-%s.json", timestamp)
-		currentData, _ := os.ReadFile(store.GetPath())
-		if len(currentData) > 0 {
-			os.WriteFile(autoBackupPath, currentData, 0644)
+		current, _ := store.LoadData()
+		if currentData, err := encodeData(store, current, clock.Now()); err == nil && len(current.Habits) > 0 {
+			os.WriteFile(autoBackupPath, currentData, 0600)
 			fmt.Printf("Current data backed up to: %s\n", autoBackupPath)
 		}
 	}
 
 	// Restore from backup
-	if err := store.Save(habits); err != nil {
+	if err := store.SaveData(backup); err != nil {
 		return fmt.Errorf("failed to restore from backup: %w", err)
 	}
 
-	fmt.Printf("✓ Restored %d habit(s) from %s\n", len(habits), backupPath)
+	fmt.Printf("✓ Restored %d habit(s) from %s\n", len(backup.Habits), backupPath)
 	return nil
 }
+
+// encodeData encodes habits and vacations as a JSON data file, encrypted if
+// the store keeps them encrypted. now is recorded as the file's creation time.
+func encodeData(store storage.Storage, data storage.Data, now time.Time) ([]byte, error) {
+	if encrypted, ok := store.(*storage.EncryptedStorage); ok {
+		return encrypted.Encode(data, now)
+	}
+	return storage.Encode(data, now)
+}
+
+// decodeData parses a JSON data file, decrypting it with the store's
+// passphrase if it is encrypted. Encrypted habits are refused if the store's
+// are not, rather than taken for a habit of their own.
+func decodeData(store storage.Storage, content []byte) (storage.Data, error) {
+	var data storage.Data
+	var err error
+	if encrypted, ok := store.(*storage.EncryptedStorage); ok {
+		data, err = encrypted.Decode(content)
+	} else {
+		data, err = storage.Decode(content)
+	}
+	if err != nil {
+		return storage.Data{}, err
+	}
+	if storage.IsEncryptedList(data.Habits) {
+		return storage.Data{}, fmt.Errorf("its habits are encrypted, but the configured storage is not; run 'habit encrypt' with the same passphrase first")
+	}
+	return data, nil
+}
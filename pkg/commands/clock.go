@@ -0,0 +1,39 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// Clock gives commands the current time and decides which calendar day it
+// falls on. Commands that depend on the date take one as a parameter; set
+// Source to a models.FixedClock to run them at a given moment, e.g. in tests
+// or when replaying historical data. The zero value uses the system clock and
+// local time.
+type Clock struct {
+	Source   models.Clock    // Where the current time comes from; the system clock if nil
+	Calendar models.Calendar // Time zone and day start hour
+}
+
+// Now returns the current time.
+func (c Clock) Now() time.Time {
+	if c.Source == nil {
+		return models.SystemClock{}.Now()
+	}
+	return c.Source.Now()
+}
+
+// Today returns the current calendar day.
+func (c Clock) Today() time.Time {
+	return c.Calendar.Day(c.Now())
+}
+
+// Location returns the time zone times are shown in.
+func (c Clock) Location() *time.Location {
+	if c.Calendar.Location != nil {
+		return c.Calendar.Location
+	}
+	return time.Local
+}
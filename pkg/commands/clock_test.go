@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// fixedClock returns a clock stopped at the given time, counting days in UTC,
+// and its source so the test can advance it.
+func fixedClock(at time.Time) (Clock, *models.FixedClock) {
+	source := &models.FixedClock{Time: at}
+	return Clock{Source: source, Calendar: models.Calendar{Location: time.UTC}}, source
+}
+
+func TestScenario_FixedClock(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	// Monday 2025-01-06, 9am
+	clock, source := fixedClock(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+
+	streak := func() int {
+		t.Helper()
+		habits, err := store.Load()
+		if err != nil {
+			t.Fatalf("Failed to load habits: %v", err)
+		}
+		h, _ := habits.Find("Reading")
+		return h.CurrentStreak(clock.Today())
+	}
+
+	steps := []struct {
+		name   string
+		run    func() error
+		days   int // Days to advance the clock after the step
+		streak int
+	}{
+		{"add", func() error { return Add(store, clock, "Reading", AddOptions{}) }, 0, 0},
+		{"mark monday", func() error { return Mark(store, clock, "Reading", MarkOptions{}) }, 1, 1},
+		{"mark tuesday", func() error { return Mark(store, clock, "Reading", MarkOptions{}) }, 2, 2},
+		{"missed wednesday", func() error { return nil }, 0, 0},
+		{"backfill wednesday", func() error { return Mark(store, clock, "Reading", MarkOptions{Date: "2025-01-08"}) }, 0, 3},
+		{"pause from thursday", func() error { return Pause(store, clock, "Reading", "2025-01-12") }, 4, 3},
+		{"mark monday after pause", func() error { return Mark(store, clock, "Reading", MarkOptions{}) }, 0, 4},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := streak(); got != step.streak {
+			t.Errorf("%s: streak = %d, want %d", step.name, got, step.streak)
+		}
+		source.Advance(step.days)
+	}
+
+	habits, _ := store.Load()
+	reading, _ := habits.Find("Reading")
+	if reading.LastDone != "2025-01-13" {
+		t.Errorf("LastDone = %s, want 2025-01-13", reading.LastDone)
+	}
+	if reading.History[len(reading.History)-1].Timestamp != clock.Now() {
+		t.Errorf("Timestamp = %v, want the clock time %v", reading.History[len(reading.History)-1].Timestamp, clock.Now())
+	}
+	if days, _ := reading.DaysSinceLastDone(time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)); days != 7 {
+		t.Errorf("DaysSinceLastDone() = %d, want 7", days)
+	}
+}
+
+func TestCalendar_DayStart(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	// 1:30am on 2025-01-16 counts for 2025-01-15 when days start at 4am
+	clock, _ := fixedClock(time.Date(2025, 1, 16, 1, 30, 0, 0, time.UTC))
+	clock.Calendar.DayStartHour = 4
+
+	if err := Mark(store, clock, "Night Shift", MarkOptions{Create: true}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+
+	habits, _ := store.Load()
+	h, _ := habits.Find("Night Shift")
+	if h.LastDone != "2025-01-15" {
+		t.Errorf("LastDone = %s, want 2025-01-15", h.LastDone)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
@@ -16,26 +17,20 @@ func Delete(store storage.Storage, habitName string) error {
 		return fmt.Errorf("habit name cannot be empty")
 	}
 
-	// Load existing habits
-	habits, err := store.Load()
+	err := store.Update(func(habits *models.HabitList) error {
+		habit, index, err := findHabit(*habits, habitName)
+		if err != nil {
+			return err
+		}
+		habitName = habit.Name
+
+		if err := habits.Remove(index); err != nil {
+			return fmt.Errorf("failed to remove habit: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load habits: %w", err)
-	}
-
-	// Find the habit
-	habit, index := habits.Find(habitName)
-	if habit == nil {
-		return fmt.Errorf("habit '%s' not found", habitName)
-	}
-
-	// Remove the habit
-	if err := habits.Remove(index); err != nil {
-		return fmt.Errorf("failed to remove habit: %w", err)
-	}
-
-	// Save updated habits
-	if err := store.Save(habits); err != nil {
-		return fmt.Errorf("failed to save habits: %w", err)
+		return err
 	}
 
 	fmt.Printf("✓ Habit '%s' has been deleted.\n", habitName)
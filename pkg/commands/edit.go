@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Edit renames a habit.
+// Edit renames a habit. The habit keeps its ID, so it can still be found by it.
 func Edit(store storage.Storage, oldName, newName string) error {
 	// Validate input
 	oldName = strings.TrimSpace(oldName)
@@ -21,30 +22,24 @@ func Edit(store storage.Storage, oldName, newName string) error {
 		return fmt.Errorf("new habit name cannot be empty")
 	}
 
-	// Load existing habits
-	habits, err := store.Load()
+	err := store.Update(func(habits *models.HabitList) error {
+		// Find the habit to edit
+		habit, index, err := findHabit(*habits, oldName)
+		if err != nil {
+			return err
+		}
+		oldName = habit.Name
+
+		// Check if new name already exists
+		if existing, i := habits.Find(newName); existing != nil && i != index {
+			return fmt.Errorf("habit '%s' already exists", newName)
+		}
+
+		habit.Name = newName
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load habits: %w", err)
-	}
-
-	// Find the habit to edit
-	habit, index := habits.Find(oldName)
-	if habit == nil {
-		return fmt.Errorf("habit '%s' not found", oldName)
-	}
-
-	// Check if new name already exists
-	if existing, _ := habits.Find(newName); existing != nil && !strings.EqualFold(oldName, newName) {
-		return fmt.Errorf("habit '%s' already exists", newName)
-	}
-
-	// Update the habit name
-	habit.Name = newName
-	habits[index] = *habit
-
-	// Save updated habits
-	if err := store.Save(habits); err != nil {
-		return fmt.Errorf("failed to save habits: %w", err)
+		return err
 	}
 
 	fmt.Printf("✓ Renamed habit '%s' to '%s'\n", oldName, newName)
@@ -0,0 +1,34 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Encrypt encrypts the habits in store with a passphrase. From then on every
+// command needs the passphrase to read or change them.
+func Encrypt(store storage.Storage, passphrase storage.PassphraseFunc) error {
+	if err := storage.Encrypt(store, passphrase); err != nil {
+		return fmt.Errorf("failed to encrypt habits: %w", err)
+	}
+
+	fmt.Printf("✓ Encrypted habits in %s\n", store.GetPath())
+	fmt.Println("  Keep the passphrase safe: the habits cannot be recovered without it.")
+	if _, err := gitStorage(store); err == nil {
+		fmt.Println("⚠ Earlier git commits still hold the unencrypted habits. Rewrite the history of")
+		fmt.Println("  the data file (e.g. with git filter-repo) if it must not keep them.")
+	}
+	return nil
+}
+
+// Decrypt stores encrypted habits without encryption again.
+func Decrypt(store storage.Storage, passphrase storage.PassphraseFunc) error {
+	if err := storage.Decrypt(store, passphrase); err != nil {
+		return fmt.Errorf("failed to decrypt habits: %w", err)
+	}
+
+	fmt.Printf("✓ Decrypted habits in %s\n", store.GetPath())
+	return nil
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestEncryptAndBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	plain := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+	passphrase := func() ([]byte, error) { return []byte("secret"), nil }
+
+	if err := Add(plain, Clock{}, "Exercise", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Encrypt(plain, passphrase); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	// Backups of encrypted habits stay encrypted and can be restored
+	store := storage.NewEncryptedStorage(plain, passphrase)
+	backupPath := filepath.Join(tmpDir, "backup.json")
+	if err := Backup(store, Clock{}, backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	backup := storage.NewJSONStorage(backupPath, storage.Options{})
+	if encrypted, err := storage.IsEncrypted(backup); err != nil || !encrypted {
+		t.Errorf("Expected the backup to be encrypted, got %v, %v", encrypted, err)
+	}
+	if err := Delete(store, "Exercise"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := Restore(store, Clock{}, backupPath); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	if err := Decrypt(plain, passphrase); err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+
+	// The encrypted backup is refused rather than restored as a habit, also
+	// through the optional encryption the CLI wraps every store in
+	for _, target := range []storage.Storage{plain, storage.NewOptionalEncryptedStorage(plain, passphrase)} {
+		if err := Restore(target, Clock{}, backupPath); err == nil {
+			t.Errorf("Expected restoring an encrypted backup into %T to fail", target)
+		}
+		if _, err := importJSON(target, backupPath); err == nil {
+			t.Errorf("Expected importing an encrypted backup into %T to fail", target)
+		}
+	}
+	habits, err := plain.Load()
+	if err != nil || len(habits) != 1 || habits[0].Name != "Exercise" {
+		t.Errorf("Expected the restored habit after decrypting, got %+v, %v", habits, err)
+	}
+}
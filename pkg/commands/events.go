@@ -0,0 +1,123 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Events shows the recorded changes to a habit, such as when it was marked,
+// renamed or reset, or to all habits if habitName is empty. Only the event
+// log storage records them. Deleted habits can be looked up by name.
+func Events(store storage.Storage, clock Clock, habitName string) error {
+	eventLog, ok := store.(*storage.EventStorage)
+	if !ok {
+		return fmt.Errorf("changes are only recorded by the event log storage (set HABIT_STORAGE=events)")
+	}
+
+	events, err := eventLog.Events()
+	if err != nil {
+		return fmt.Errorf("failed to read events: %w", err)
+	}
+
+	habitName = strings.TrimSpace(habitName)
+	id := ""
+	if habitName != "" {
+		if id, err = eventHabitID(store, events, habitName); err != nil {
+			return err
+		}
+	}
+
+	// Names as of each event, so renames can show the previous one
+	names := make(map[string]string)
+	shown := 0
+	for _, event := range events {
+		previous := names[event.HabitID]
+		if event.Type == storage.EventSnapshot {
+			for _, h := range event.Habits {
+				names[h.ID] = h.Name
+			}
+		} else if event.HabitID != "" {
+			names[event.HabitID] = event.Name
+		}
+
+		if id != "" && event.HabitID != id {
+			continue
+		}
+		fmt.Println(formatEvent(event, previous, clock.Location()))
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println("No events recorded.")
+	}
+	return nil
+}
+
+// eventHabitID finds the ID of a current habit, or of a deleted one whose
+// name appears in the events.
+func eventHabitID(store storage.Storage, events []storage.Event, habitName string) (string, error) {
+	habits, err := store.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load habits: %w", err)
+	}
+	habit, _, err := findHabit(habits, habitName)
+	if err == nil {
+		return habit.ID, nil
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].HabitID != "" && strings.EqualFold(events[i].Name, habitName) {
+			return events[i].HabitID, nil
+		}
+	}
+	return "", err
+}
+
+// formatEvent describes an event on one line, e.g.
+// "2025-01-15 07:30  #3f9a01c2  Exercise: marked 2025-01-15 (5k)". previous
+// is the habit's name before the event.
+func formatEvent(e storage.Event, previous string, loc *time.Location) string {
+	when := e.Time.In(loc).Format("2006-01-02 15:04")
+	switch e.Type {
+	case storage.EventSnapshot:
+		return fmt.Sprintf("%s  snapshot of %d habit(s)", when, len(e.Habits))
+	case storage.EventVacations:
+		return fmt.Sprintf("%s  vacations changed (%d on record)", when, len(e.Vacations))
+	}
+
+	var what string
+	switch e.Type {
+	case storage.EventCreate:
+		what = "created"
+	case storage.EventDelete:
+		what = "deleted"
+	case storage.EventRename:
+		what = "renamed from '" + previous + "'"
+	case storage.EventMark:
+		what = "marked " + e.Date
+		if e.Completion != nil {
+			var details []string
+			if e.Completion.Amount != 0 {
+				details = append(details, models.FormatAmount(e.Completion.Amount))
+			}
+			if e.Completion.Note != "" {
+				details = append(details, e.Completion.Note)
+			}
+			if len(details) > 0 {
+				what += " (" + strings.Join(details, ", ") + ")"
+			}
+		}
+	case storage.EventUnmark:
+		what = "unmarked " + e.Date
+	case storage.EventReset:
+		what = "reset"
+	case storage.EventUpdate:
+		what = "settings changed"
+	default:
+		what = string(e.Type)
+	}
+	return fmt.Sprintf("%s  #%s  %s: %s", when, e.HabitID, e.Name, what)
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := storage.NewEventStorage(filepath.Join(tmpDir, "habits.jsonl"), storage.Options{})
+
+	if err := Add(store, Clock{}, "Exercise", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Mark(store, Clock{}, "Exercise", MarkOptions{}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+	if err := Reset(store, Clock{}, "Exercise"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if err := Events(store, Clock{}, "Exercise"); err != nil {
+		t.Errorf("Events failed: %v", err)
+	}
+
+	// A deleted habit can still be looked up by name
+	if err := Delete(store, "Exercise"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := Events(store, Clock{}, "exercise"); err != nil {
+		t.Errorf("Events of a deleted habit failed: %v", err)
+	}
+	if err := Events(store, Clock{}, "Reading"); err == nil {
+		t.Error("Expected error for a habit that never existed")
+	}
+
+	jsonStore := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+	if err := Events(jsonStore, Clock{}, ""); err == nil {
+		t.Error("Expected error for storage without an event log")
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	at := time.Date(2025, 1, 15, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		event    storage.Event
+		previous string
+		want     string
+	}{
+		{
+			name:  "mark with note",
+			event: storage.Event{Time: at, Type: storage.EventMark, HabitID: "a1b2c3d4", Name: "Exercise", Date: "2025-01-15", Completion: &models.Completion{Date: "2025-01-15", Note: "5k"}},
+			want:  "2025-01-15 07:30  #a1b2c3d4  Exercise: marked 2025-01-15 (5k)",
+		},
+		{
+			name:     "rename",
+			event:    storage.Event{Time: at, Type: storage.EventRename, HabitID: "a1b2c3d4", Name: "Running"},
+			previous: "Exercise",
+			want:     "2025-01-15 07:30  #a1b2c3d4  Running: renamed from 'Exercise'",
+		},
+		{
+			name:  "reset",
+			event: storage.Event{Time: at, Type: storage.EventReset, HabitID: "a1b2c3d4", Name: "Exercise"},
+			want:  "2025-01-15 07:30  #a1b2c3d4  Exercise: reset",
+		},
+		{
+			name:  "snapshot",
+			event: storage.Event{Time: at, Type: storage.EventSnapshot, Habits: models.HabitList{{Name: "Exercise"}, {Name: "Reading"}}},
+			want:  "2025-01-15 07:30  snapshot of 2 habit(s)",
+		},
+		{
+			name:  "vacations",
+			event: storage.Event{Time: at, Type: storage.EventVacations, Vacations: models.Vacations{{Start: "2025-01-20", End: "2025-01-27"}}},
+			want:  "2025-01-15 07:30  vacations changed (1 on record)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatEvent(tt.event, tt.previous, time.UTC); got != tt.want {
+				t.Errorf("formatEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
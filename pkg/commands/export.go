@@ -3,10 +3,10 @@ package commands
 
 import (
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -14,8 +14,14 @@ import (
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Export exports habits to various formats (CSV, JSON).
-func Export(store storage.Storage, format, outputPath string) error {
+// ExportOptions holds optional settings for the export command.
+type ExportOptions struct {
+	Tag string // Only export habits with this tag
+}
+
+// Export exports habits to various formats (CSV, JSON). JSON exports are
+// unencrypted data files that include the vacations.
+func Export(store storage.Storage, clock Clock, format, outputPath string, opts ExportOptions) error {
 	// Validate format
 	format = strings.ToLower(strings.TrimSpace(format))
 	if format != "csv" && format != "json" {
@@ -23,15 +29,21 @@ func Export(store storage.Storage, format, outputPath string) error {
 	}
 
 	// Load habits
-	habits, err := store.Load()
+	data, err := store.LoadData()
 	if err != nil {
 		return fmt.Errorf("failed to load habits: %w", err)
 	}
+	habits := data.Habits
 
 	if len(habits) == 0 {
 		return fmt.Errorf("no habits to export")
 	}
 
+	habits = habits.FilterByTag(opts.Tag)
+	if len(habits) == 0 {
+		return fmt.Errorf("no habits tagged '%s' to export", models.NormalizeTag(opts.Tag))
+	}
+
 	// Create output directory if needed
 	dir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -41,15 +53,15 @@ func Export(store storage.Storage, format, outputPath string) error {
 	// Export based on format
 	switch format {
 	case "csv":
-		return exportCSV(habits, outputPath)
+		return exportCSV(habits, data.Vacations, outputPath)
 	case "json":
-		return exportJSON(habits, outputPath)
+		return exportJSON(storage.Data{Habits: habits, Vacations: data.Vacations}, clock, outputPath)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
-func exportCSV(habits models.HabitList, outputPath string) error {
+func exportCSV(habits models.HabitList, vacations models.Vacations, outputPath string) error {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -60,7 +72,7 @@ func exportCSV(habits models.HabitList, outputPath string) error {
 	defer writer.Flush()
 
 	// Write header
-	if err := writer.Write([]string{"Name", "Last Done", "Streak"}); err != nil {
+	if err := writer.Write([]string{"Name", "Last Done", "Streak", "History", "Tags", "ID", "Schedule", "Target", "Unit", "Amounts", "Notes", "Start Date"}); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
@@ -70,10 +82,35 @@ func exportCSV(habits models.HabitList, outputPath string) error {
 		if lastDone == "" {
 			lastDone = "Never"
 		}
+		dates := make([]string, len(habit.History))
+		var amounts, notes []string
+		for i, c := range habit.History {
+			dates[i] = c.Date
+			if c.Amount != 0 {
+				amounts = append(amounts, c.Date+"="+models.FormatAmount(c.Amount))
+			}
+			if c.Note != "" {
+				notes = append(notes, c.Date+": "+c.Note)
+			}
+		}
+		target := ""
+		if habit.Target > 0 {
+			target = models.FormatAmount(habit.Target)
+		}
+
 		row := []string{
 			habit.Name,
 			lastDone,
 			strconv.Itoa(habit.Streak),
+			strings.Join(dates, ";"),
+			strings.Join(habit.Tags, ";"),
+			habit.ID,
+			habit.Schedule.Spec(),
+			target,
+			habit.Unit,
+			strings.Join(amounts, ";"),
+			strings.Join(notes, "\n"),
+			habit.StartDate,
 		}
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
@@ -81,19 +118,44 @@ func exportCSV(habits models.HabitList, outputPath string) error {
 	}
 
 	fmt.Printf("✓ Exported %d habit(s) to %s\n", len(habits), outputPath)
+	if lost := csvLeftOut(habits, vacations); len(lost) > 0 {
+		fmt.Printf("⚠  CSV has no columns for %s; use 'habit export json' for a complete copy.\n", strings.Join(lost, ", "))
+	}
 	return nil
 }
 
-func exportJSON(habits models.HabitList, outputPath string) error {
-	data, err := json.MarshalIndent(habits, "", "  ")
+// csvLeftOut lists the kinds of habit data that the habits and vacations
+// have and a CSV export cannot hold, e.g. "breaks".
+func csvLeftOut(habits models.HabitList, vacations models.Vacations) []string {
+	var lost []string
+	has := func(what string, found func(h models.Habit) bool) {
+		if slices.ContainsFunc(habits, found) {
+			lost = append(lost, what)
+		}
+	}
+	has("avoid habits", func(h models.Habit) bool { return h.IsAvoid() })
+	has("descriptions", func(h models.Habit) bool { return h.Description != "" })
+	has("colors", func(h models.Habit) bool { return h.Color != "" })
+	has("archived habits", func(h models.Habit) bool { return h.Archived })
+	has("breaks", func(h models.Habit) bool { return len(h.Breaks) > 0 })
+	has("freeze tokens", func(h models.Habit) bool { return h.Freezes > 0 })
+	has("streak resets", func(h models.Habit) bool { return h.ResetDate != "" })
+	if len(vacations) > 0 {
+		lost = append(lost, "vacations")
+	}
+	return lost
+}
+
+func exportJSON(data storage.Data, clock Clock, outputPath string) error {
+	content, err := storage.Encode(data, clock.Now())
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+	if err := os.WriteFile(outputPath, content, 0600); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	fmt.Printf("✓ Exported %d habit(s) to %s\n", len(habits), outputPath)
+	fmt.Printf("✓ Exported %d habit(s) to %s\n", len(data.Habits), outputPath)
 	return nil
 }
@@ -3,6 +3,7 @@ package commands
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
@@ -13,7 +14,7 @@ func TestExport_CSV(t *testing.T) {
 	// Setup
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	// Create test data
 	habits := models.HabitList{
@@ -26,7 +27,7 @@ func TestExport_CSV(t *testing.T) {
 
 	// Export to CSV
 	outputPath := filepath.Join(tmpDir, "export.csv")
-	err := Export(store, "csv", outputPath)
+	err := Export(store, Clock{}, "csv", outputPath, ExportOptions{})
 	if err != nil {
 		t.Fatalf("Export failed: %v", err)
 	}
@@ -48,10 +49,85 @@ func TestExport_CSV(t *testing.T) {
 	}
 }
 
+func TestExport_CSVRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+
+	threePerWeek, _ := models.ParseSchedule("3/week")
+	habits := models.HabitList{
+		{
+			Name: "Water", ID: "water", StartDate: "2025-01-01", Target: 8, Unit: "glasses",
+			History: []models.Completion{
+				{Date: "2025-01-02", Amount: 8, Note: "easy"},
+				{Date: "2025-01-03", Amount: 2.5, Note: "travelling;\nforgot the bottle"},
+			},
+		},
+		{
+			Name: "Gym", ID: "gym", Schedule: threePerWeek,
+			History: []models.Completion{{Date: "2025-01-02"}, {Date: "2025-01-04", Note: "legs: heavy"}},
+		},
+	}
+	for i := range habits {
+		if err := habits[i].Recalculate(); err != nil {
+			t.Fatalf("Recalculate failed: %v", err)
+		}
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "export.csv")
+	if err := Export(store, Clock{}, "csv", outputPath, ExportOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	imported := storage.NewJSONStorage(filepath.Join(tmpDir, "imported.json"), storage.Options{})
+	if err := Import(imported, "csv", outputPath, false); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	loaded, err := imported.Load()
+	if err != nil {
+		t.Fatalf("Failed to load habits: %v", err)
+	}
+	for _, want := range habits {
+		got, _ := loaded.Find(want.Name)
+		if got == nil {
+			t.Fatalf("Habit %s missing after import", want.Name)
+		}
+		if got.Schedule.String() != want.Schedule.String() || got.Target != want.Target ||
+			got.Unit != want.Unit || got.StartDate != want.StartDate || got.Streak != want.Streak {
+			t.Errorf("Imported %+v, want %+v", *got, want)
+		}
+		if len(got.History) != len(want.History) {
+			t.Fatalf("Imported history %v, want %v", got.History, want.History)
+		}
+		for i, c := range got.History {
+			if c.Date != want.History[i].Date || c.Amount != want.History[i].Amount || c.Note != want.History[i].Note {
+				t.Errorf("Imported completion %+v, want %+v", c, want.History[i])
+			}
+		}
+	}
+}
+
+func TestCSVLeftOut(t *testing.T) {
+	habits := models.HabitList{
+		{Name: "Smoking", Kind: models.KindAvoid},
+		{Name: "Reading", Description: "Before bed", Breaks: []models.Break{{Start: "2025-01-01"}}},
+	}
+	got := csvLeftOut(habits, nil)
+	want := []string{"avoid habits", "descriptions", "breaks"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("csvLeftOut() = %v, want %v", got, want)
+	}
+	if lost := csvLeftOut(models.HabitList{{Name: "Water", Target: 8, StartDate: "2025-01-01"}}, nil); len(lost) != 0 {
+		t.Errorf("csvLeftOut() = %v for data CSV holds", lost)
+	}
+}
+
 func TestExport_JSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	habits := models.HabitList{
 		{Name: "Exercise", LastDone: "2025-01-15", Streak: 5},
@@ -61,7 +137,7 @@ func TestExport_JSON(t *testing.T) {
 	}
 
 	outputPath := filepath.Join(tmpDir, "export.json")
-	err := Export(store, "json", outputPath)
+	err := Export(store, Clock{}, "json", outputPath, ExportOptions{})
 	if err != nil {
 		t.Fatalf("Export failed: %v", err)
 	}
@@ -74,9 +150,9 @@ func TestExport_JSON(t *testing.T) {
 func TestExport_InvalidFormat(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
-	err := Export(store, "xml", filepath.Join(tmpDir, "export.xml"))
+	err := Export(store, Clock{}, "xml", filepath.Join(tmpDir, "export.xml"), ExportOptions{})
 	if err == nil {
 		t.Error("Expected error for invalid format, got nil")
 	}
@@ -85,9 +161,9 @@ func TestExport_InvalidFormat(t *testing.T) {
 func TestExport_EmptyHabits(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
-	err := Export(store, "csv", filepath.Join(tmpDir, "export.csv"))
+	err := Export(store, Clock{}, "csv", filepath.Join(tmpDir, "export.csv"), ExportOptions{})
 	if err == nil {
 		t.Error("Expected error for empty habits, got nil")
 	}
@@ -0,0 +1,59 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Freeze spends one of a habit's freeze tokens to cover a missed day
+// ("today", "yesterday" or YYYY-MM-DD; default today) so it does not break
+// the streak.
+func Freeze(store storage.Storage, clock Clock, habitName, date string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	today := clock.Today()
+	date, err := models.ResolveDate(date, today)
+	if err != nil {
+		return err
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		return habit.UseFreeze(date)
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	fmt.Printf("❄  Froze '%s' on %s. Current streak: %d %s(s), %d freeze(s) left\n",
+		habitName, date, habit.CurrentStreak(today), habit.Schedule.Unit(), habit.Freezes)
+	return nil
+}
+
+// AddFreezes gives a habit n more freeze tokens.
+func AddFreezes(store storage.Storage, habitName string, n int) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		return habit.AddFreezes(n)
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	fmt.Printf("✓ '%s' now has %d freeze(s)\n", habitName, habit.Freezes)
+	return nil
+}
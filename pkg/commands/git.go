@@ -0,0 +1,105 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// GitHistory lists the commits of a git-backed data file, newest first. With
+// a habit name, only commits whose message mentions the habit are listed.
+func GitHistory(store storage.Storage, clock Clock, habitName string) error {
+	git, err := gitStorage(store)
+	if err != nil {
+		return err
+	}
+
+	commits, err := git.History(0)
+	if err != nil {
+		return fmt.Errorf("failed to read git history: %w", err)
+	}
+
+	habitName = strings.TrimSpace(habitName)
+	if habitName != "" {
+		commits = commitsMentioning(store, commits, habitName)
+	}
+
+	if len(commits) == 0 {
+		fmt.Println("No commits yet.")
+		return nil
+	}
+
+	fmt.Printf("📜 Git history of %s (%d commit(s)):\n\n", git.GetPath(), len(commits))
+	for _, c := range commits {
+		fmt.Printf("  %s  %s  %s\n", c.Time.In(clock.Location()).Format("2006-01-02 15:04"), c.Short, c.Subject)
+	}
+	fmt.Println("\nTo go back to a version, use: habit revert <commit>")
+	return nil
+}
+
+// Revert restores the habits of a git-backed data file as they were after a
+// commit. The current version stays in the history.
+func Revert(store storage.Storage, rev string) error {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return fmt.Errorf("commit cannot be empty")
+	}
+
+	git, err := gitStorage(store)
+	if err != nil {
+		return err
+	}
+
+	commit, err := git.Revert(rev)
+	if err != nil {
+		return fmt.Errorf("failed to revert: %w", err)
+	}
+
+	fmt.Printf("✓ Reverted habits to %s (%s)\n", commit.Short, commit.Subject)
+	return nil
+}
+
+// commitsMentioning returns the commits whose message mentions a habit,
+// under its current name or any name it had before being renamed.
+func commitsMentioning(store storage.Storage, commits []storage.Commit, habitName string) []storage.Commit {
+	names := map[string]bool{strings.ToLower(habitName): true}
+	if habits, err := store.Load(); err == nil {
+		if habit, _, err := findHabit(habits, habitName); err == nil {
+			names[strings.ToLower(habit.Name)] = true
+		}
+	}
+
+	var matching []storage.Commit
+	for _, c := range commits {
+		subject := strings.ToLower(c.Subject)
+		for name := range names {
+			if strings.Contains(subject, name) {
+				matching = append(matching, c)
+				break
+			}
+		}
+		// Older commits use the name from before a rename
+		if rename, ok := strings.CutPrefix(subject, "rename: "); ok {
+			if from, to, ok := strings.Cut(rename, " -> "); ok && names[to] {
+				names[from] = true
+			}
+		}
+	}
+	return matching
+}
+
+// gitStorage finds the git-backed storage, also behind encryption.
+func gitStorage(store storage.Storage) (*storage.GitStorage, error) {
+	for {
+		switch s := store.(type) {
+		case *storage.GitStorage:
+			return s, nil
+		case interface{ Unwrap() storage.Storage }:
+			store = s.Unwrap()
+		default:
+			return nil, fmt.Errorf("habits are not kept in git (use a git data URL, e.g. HABIT_DATA_URL=git://~/.habit-tracker/habits.json)")
+		}
+	}
+}
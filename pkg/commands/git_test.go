@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestGitHistoryAndRevert(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	tmpDir := t.TempDir()
+	store := storage.NewGitStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+
+	if err := Add(store, Clock{}, "Exercise", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Edit(store, "Exercise", "Workout"); err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+
+	// Commits from before the rename still match the new name
+	commits, err := store.History(0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if got := commitsMentioning(store, commits, "Workout"); len(got) != 2 {
+		t.Errorf("Expected both commits to mention the habit, got %+v", got)
+	}
+	if err := GitHistory(store, Clock{}, ""); err != nil {
+		t.Errorf("GitHistory failed: %v", err)
+	}
+
+	if err := Revert(store, commits[1].Hash); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	habits, _ := store.Load()
+	if len(habits) != 1 || habits[0].Name != "Exercise" {
+		t.Errorf("Expected the habit's old name after revert, got %+v", habits)
+	}
+
+	jsonStore := storage.NewJSONStorage(filepath.Join(tmpDir, "plain.json"), storage.Options{})
+	if err := Revert(jsonStore, "HEAD"); err == nil {
+		t.Error("Expected error for storage without git")
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestHabitIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	if err := Add(store, Clock{}, "Exercise", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	loaded, _ := store.Load()
+	id := loaded[0].ID
+	if id == "" {
+		t.Fatal("Expected new habit to have an ID")
+	}
+
+	// Renaming keeps the ID, and the habit can be found by it or a prefix
+	if err := Edit(store, id, "Workout"); err != nil {
+		t.Fatalf("Edit by ID failed: %v", err)
+	}
+	if err := Mark(store, Clock{}, id[:4], MarkOptions{}); err != nil {
+		t.Fatalf("Mark by ID prefix failed: %v", err)
+	}
+	loaded, _ = store.Load()
+	if loaded[0].Name != "Workout" || loaded[0].ID != id || len(loaded[0].History) != 1 {
+		t.Errorf("Unexpected habit after rename and mark: %+v", loaded[0])
+	}
+
+	// An import of the old name with the same ID merges into the renamed habit
+	importPath := filepath.Join(tmpDir, "import.json")
+	data, _ := json.Marshal(models.HabitList{{ID: id, Name: "Exercise"}, {Name: "Reading"}})
+	os.WriteFile(importPath, data, 0644)
+	if err := Import(store, "json", importPath, true); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	loaded, _ = store.Load()
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 habits after merge, got %d", len(loaded))
+	}
+	if h, _ := loaded.FindByID(id); h == nil || h.Name != "Exercise" {
+		t.Errorf("Expected habit %s to be merged by ID, got %+v", id, h)
+	}
+}
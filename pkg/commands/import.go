@@ -3,17 +3,18 @@ package commands
 
 import (
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Import imports habits from various formats (CSV, JSON).
+// Import imports habits from various formats (CSV, JSON). Vacations in a
+// JSON data file are added to the current ones in either mode.
 func Import(store storage.Storage, format, inputPath string, merge bool) error {
 	// Validate format
 	format = strings.ToLower(strings.TrimSpace(format))
@@ -27,14 +28,14 @@ func Import(store storage.Storage, format, inputPath string, merge bool) error {
 	}
 
 	// Import based on format
-	var importedHabits models.HabitList
+	var source storage.Data
 	var err error
 
 	switch format {
 	case "csv":
-		importedHabits, err = importCSV(inputPath)
+		source.Habits, err = importCSV(inputPath)
 	case "json":
-		importedHabits, err = importJSON(inputPath)
+		source, err = importJSON(store, inputPath)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
@@ -42,6 +43,7 @@ func Import(store storage.Storage, format, inputPath string, merge bool) error {
 	if err != nil {
 		return err
 	}
+	importedHabits := source.Habits
 
 	// Validate imported habits
 	for i, habit := range importedHabits {
@@ -53,40 +55,59 @@ func Import(store storage.Storage, format, inputPath string, merge bool) error {
 	if len(importedHabits) == 0 {
 		return fmt.Errorf("no habits found in file")
 	}
+	if err := source.Vacations.Validate(); err != nil {
+		return fmt.Errorf("invalid vacation: %w", err)
+	}
+
+	// Build completion histories for files written by older versions
+	if err := importedHabits.MigrateHistory(); err != nil {
+		return fmt.Errorf("invalid habit data: %w", err)
+	}
+	importedHabits.EnsureIDs()
 
 	// Handle merge vs replace
 	if merge {
-		// Load existing habits
-		existingHabits, err := store.Load()
-		if err != nil {
-			return fmt.Errorf("failed to load existing habits: %w", err)
-		}
-
-		// Merge: update existing, add new
+		// Merge: update existing, add new. Habits are matched by ID first so
+		// that renamed habits are still recognised, then by name. The data
+		// file stays locked from load to save.
 		merged := 0
 		added := 0
-		for _, imported := range importedHabits {
-			existing, index := existingHabits.Find(imported.Name)
-			if existing != nil {
-				// Update existing habit
-				existingHabits[index] = imported
-				merged++
-			} else {
-				// Add new habit
-				existingHabits = append(existingHabits, imported)
-				added++
+		err := store.UpdateData(func(data *storage.Data) error {
+			data.Vacations = models.MergeVacations(nil, data.Vacations, source.Vacations)
+			existingHabits := &data.Habits
+			for _, imported := range importedHabits {
+				existing, index := existingHabits.FindByID(imported.ID)
+				if existing == nil {
+					existing, index = existingHabits.Find(imported.Name)
+				}
+				if existing != nil {
+					// Update existing habit, keeping the ID it is known by
+					imported.ID = existing.ID
+					(*existingHabits)[index] = imported
+					merged++
+				} else {
+					// Add new habit
+					if err := existingHabits.Add(imported); err != nil {
+						return fmt.Errorf("failed to add habit '%s': %w", imported.Name, err)
+					}
+					added++
+				}
 			}
-		}
-
-		// Save merged habits
-		if err := store.Save(existingHabits); err != nil {
-			return fmt.Errorf("failed to save habits: %w", err)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
 		fmt.Printf("✓ Imported %d habit(s): %d merged, %d added\n", len(importedHabits), merged, added)
 	} else {
 		// Replace all habits
-		if err := store.Save(importedHabits); err != nil {
+		err := store.UpdateData(func(data *storage.Data) error {
+			data.Habits = importedHabits
+			data.Vacations = models.MergeVacations(nil, data.Vacations, source.Vacations)
+			return nil
+		})
+		if err != nil {
 			return fmt.Errorf("failed to save habits: %w", err)
 		}
 
@@ -143,22 +164,160 @@ func importCSV(inputPath string) (models.HabitList, error) {
 			Streak:   streak,
 		}
 
+		// Optional fourth column holds the completion history
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			for _, date := range strings.Split(record[3], ";") {
+				habit.History = append(habit.History, models.Completion{Date: strings.TrimSpace(date)})
+			}
+		}
+
+		// Optional fifth column holds the tags
+		if len(record) > 4 && strings.TrimSpace(record[4]) != "" {
+			for _, tag := range strings.Split(record[4], ";") {
+				if err := habit.AddTag(tag); err != nil {
+					return nil, fmt.Errorf("invalid tag at row %d: %w", i+1, err)
+				}
+			}
+		}
+
+		// Optional sixth column holds the habit ID
+		if len(record) > 5 {
+			habit.ID = strings.TrimSpace(record[5])
+		}
+
+		// Optional seventh to eleventh columns hold the schedule, the target
+		// and its unit, the amounts logged and the notes
+		if len(record) > 6 && strings.TrimSpace(record[6]) != "" {
+			schedule, err := models.ParseSchedule(record[6])
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule at row %d: %w", i+1, err)
+			}
+			if !schedule.IsDaily() {
+				habit.Schedule = schedule
+			}
+		}
+		var target float64
+		var unit string
+		if len(record) > 7 && strings.TrimSpace(record[7]) != "" {
+			if target, err = models.ParseAmount(record[7]); err != nil {
+				return nil, fmt.Errorf("invalid target at row %d: %w", i+1, err)
+			}
+		}
+		if len(record) > 8 {
+			unit = record[8]
+		}
+		if len(record) > 9 {
+			if err := importAmounts(&habit, record[9]); err != nil {
+				return nil, fmt.Errorf("invalid amounts at row %d: %w", i+1, err)
+			}
+		}
+		if len(record) > 10 {
+			if err := importNotes(&habit, record[10]); err != nil {
+				return nil, fmt.Errorf("invalid notes at row %d: %w", i+1, err)
+			}
+		}
+
+		// Optional twelfth column holds the start date
+		if len(record) > 11 {
+			habit.StartDate = strings.TrimSpace(record[11])
+		}
+
+		switch {
+		case target > 0:
+			// Days completed without an amount are taken to have reached the target
+			if err := habit.SetTarget(target, unit); err != nil {
+				return nil, fmt.Errorf("invalid target at row %d: %w", i+1, err)
+			}
+		case len(habit.History) > 0:
+			if err := habit.Recalculate(); err != nil {
+				return nil, fmt.Errorf("invalid history at row %d: %w", i+1, err)
+			}
+		}
+
 		habits = append(habits, habit)
 	}
 
 	return habits, nil
 }
 
-func importJSON(inputPath string) (models.HabitList, error) {
-	data, err := os.ReadFile(inputPath)
+// importAmounts sets the amounts of a CSV Amounts column, written as
+// "YYYY-MM-DD=amount" entries separated by semicolons, on the habit's history.
+func importAmounts(habit *models.Habit, column string) error {
+	for _, entry := range strings.Split(column, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		date, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("'%s' (expected YYYY-MM-DD=amount)", entry)
+		}
+		amount, err := models.ParseAmount(value)
+		if err != nil {
+			return err
+		}
+		completion, err := historyEntry(habit, date)
+		if err != nil {
+			return err
+		}
+		completion.Amount = amount
+	}
+	return nil
+}
+
+// importNotes sets the notes of a CSV Notes column, written one per line as
+// "YYYY-MM-DD: note", on the habit's history. A line without a date continues
+// the note before it.
+func importNotes(habit *models.Habit, column string) error {
+	var completion *models.Completion
+	for _, line := range strings.Split(column, "\n") {
+		date, note, ok := strings.Cut(line, ": ")
+		if _, err := time.Parse(models.DateFormat, strings.TrimSpace(date)); !ok || err != nil {
+			if completion == nil {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				return fmt.Errorf("'%s' (expected YYYY-MM-DD: note)", line)
+			}
+			completion.Note += "\n" + line
+			continue
+		}
+		var err error
+		if completion, err = historyEntry(habit, date); err != nil {
+			return err
+		}
+		completion.Note = note
+	}
+	return nil
+}
+
+// historyEntry returns the habit's history entry on the given date, adding
+// one if the History column did not list it.
+func historyEntry(habit *models.Habit, date string) (*models.Completion, error) {
+	date = strings.TrimSpace(date)
+	if _, err := time.Parse(models.DateFormat, date); err != nil {
+		return nil, fmt.Errorf("invalid date '%s' (expected YYYY-MM-DD)", date)
+	}
+	for i := range habit.History {
+		if habit.History[i].Date == date {
+			return &habit.History[i], nil
+		}
+	}
+	habit.History = append(habit.History, models.Completion{Date: date})
+	return &habit.History[len(habit.History)-1], nil
+}
+
+func importJSON(store storage.Storage, inputPath string) (storage.Data, error) {
+	content, err := os.ReadFile(inputPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return storage.Data{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var habits models.HabitList
-	if err := json.Unmarshal(data, &habits); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	// Accepts exports, data files and backups, as well as the bare lists
+	// older versions exported; encrypted habits are decrypted
+	data, err := decodeData(store, content)
+	if err != nil {
+		return storage.Data{}, fmt.Errorf("failed to read JSON: %w", err)
 	}
 
-	return habits, nil
+	return data, nil
 }
@@ -14,7 +14,7 @@ import (
 func TestImport_JSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	// Create import file
 	importPath := filepath.Join(tmpDir, "import.json")
@@ -47,7 +47,7 @@ func TestImport_JSON(t *testing.T) {
 func TestImport_CSV(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	// Create CSV import file
 	importPath := filepath.Join(tmpDir, "import.csv")
@@ -79,7 +79,7 @@ func TestImport_CSV(t *testing.T) {
 func TestImport_MergeMode(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	// Create existing habits
 	existing := models.HabitList{
@@ -127,7 +127,7 @@ func TestImport_MergeMode(t *testing.T) {
 func TestImport_FileNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	err := Import(store, "json", filepath.Join(tmpDir, "nonexistent.json"), false)
 	if err == nil {
@@ -138,7 +138,7 @@ func TestImport_FileNotFound(t *testing.T) {
 func TestImport_InvalidFormat(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	err := Import(store, "xml", filepath.Join(tmpDir, "import.xml"), false)
 	if err == nil {
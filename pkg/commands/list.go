@@ -3,12 +3,22 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/codeforgood-org/cli-habit-tracker-go/internal/color"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// List displays all tracked habits with their streaks.
-func List(store storage.Storage) error {
+// ListOptions holds optional settings for the list command.
+type ListOptions struct {
+	Tag      string // Only show habits with this tag
+	Archived bool   // Show archived habits instead of active ones
+}
+
+// List displays all tracked habits with their streaks. Archived habits are
+// hidden unless opts.Archived is set, in which case only they are shown.
+func List(store storage.Storage, clock Clock, opts ListOptions) error {
 	habits, err := store.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load habits: %w", err)
@@ -17,14 +27,75 @@ func List(store storage.Storage) error {
 	if len(habits) == 0 {
 		fmt.Println("No habits tracked.")
 		fmt.Println("\nTo start tracking a habit, use:")
-		fmt.Println("  habit mark <habit-name>")
+		fmt.Println("  habit add <habit-name>")
+		return nil
+	}
+
+	habits = habits.FilterByTag(opts.Tag)
+	if len(habits) == 0 {
+		fmt.Printf("No habits tagged '%s'.\n", models.NormalizeTag(opts.Tag))
 		return nil
 	}
 
-	fmt.Printf("📋 Tracking %d habit(s):\n\n", len(habits))
+	today := clock.Today()
+	archived := habits.Archived()
+
+	if opts.Archived {
+		if len(archived) == 0 {
+			fmt.Println("No archived habits.")
+			return nil
+		}
+		fmt.Printf("🗄  %d archived habit(s):\n\n", len(archived))
+		habits = archived
+	} else {
+		habits = habits.Active()
+		if len(habits) == 0 {
+			fmt.Printf("No active habits. %d archived habit(s) are hidden (see: habit list --archived).\n", len(archived))
+			return nil
+		}
+		fmt.Printf("📋 Tracking %d habit(s):\n\n", len(habits))
+	}
+
 	for _, h := range habits {
-		fmt.Println(h.String())
+		line := h.Summary(today) + scheduleStatus(&h, today) + targetStatus(&h, today) + cleanStatus(&h, today) + breakStatus(&h, today)
+		if code, ok := color.ByName(h.Color); ok {
+			line = color.Colorize(line, code)
+		}
+		fmt.Println(line + color.Dim("  #"+h.ID))
+		if h.Description != "" {
+			fmt.Println("    " + h.Description)
+		}
+	}
+
+	if !opts.Archived && len(archived) > 0 {
+		fmt.Printf("\n%d archived habit(s) hidden (see: habit list --archived)\n", len(archived))
 	}
 
 	return nil
 }
+
+// scheduleStatus describes where a non-daily habit stands in its schedule,
+// e.g. " | 2/3 this week" or " | Due today", or when a habit starts tracking.
+func scheduleStatus(h *models.Habit, today time.Time) string {
+	if h.StartDate > today.Format(models.DateFormat) && !h.IsAvoid() {
+		return " | Starts " + h.StartDate
+	}
+	if h.Schedule.IsDaily() {
+		return ""
+	}
+	if done, target := h.Progress(today); target > 0 {
+		return fmt.Sprintf(" | %d/%d this %s", done, target, h.Schedule.Unit())
+	}
+	if h.IsDue(today) {
+		return " | Due today"
+	}
+	return ""
+}
+
+// targetStatus shows today's progress of a measurable habit, e.g. " | Today: 5/8 glasses".
+func targetStatus(h *models.Habit, today time.Time) string {
+	if !h.IsQuantitative() {
+		return ""
+	}
+	return " | Today: " + formatProgress(h, today.Format(models.DateFormat))
+}
@@ -0,0 +1,63 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Log prints the dated completion history of a habit, newest first, with
+// amounts and notes.
+func Log(store storage.Storage, habitName string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	habits, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load habits: %w", err)
+	}
+
+	habit, _, err := findHabit(habits, habitName)
+	if err != nil {
+		return err
+	}
+
+	if len(habit.History) == 0 {
+		fmt.Printf("No history for '%s' yet.\n", habit.Name)
+		return nil
+	}
+
+	entries := "completion(s)"
+	if habit.IsAvoid() {
+		entries = "relapse(s)"
+	}
+	fmt.Printf("📖 History of '%s' (#%s, %d %s):\n", habit.Name, habit.ID, len(habit.History), entries)
+	if habit.Description != "" {
+		fmt.Printf("   %s\n", habit.Description)
+	}
+	fmt.Println()
+
+	for i := len(habit.History) - 1; i >= 0; i-- {
+		fmt.Println(formatLogEntry(habit, habit.History[i]))
+	}
+
+	return nil
+}
+
+// formatLogEntry formats a single history entry, e.g. "  2025-01-15  5/8 glasses  felt great".
+func formatLogEntry(h *models.Habit, c models.Completion) string {
+	line := "  " + c.Date
+	if h.IsQuantitative() {
+		line += "  " + formatProgress(h, c.Date)
+	}
+	if c.Note != "" {
+		line += "  " + c.Note
+	}
+	return line
+}
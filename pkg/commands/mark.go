@@ -2,64 +2,188 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Mark marks a habit as completed for today.
-func Mark(store storage.Storage, habitName string) error {
+// MarkOptions holds optional settings for the mark command.
+type MarkOptions struct {
+	Date   string  // Date to mark: "today", "yesterday" or YYYY-MM-DD (default today)
+	Amount float64 // Amount to log for habits with a target (default 1)
+	Note   string  // Note to attach to the completion
+	Create bool    // Create the habit if it does not exist
+}
+
+// Mark marks a habit as completed for today, or for a past date when
+// opts.Date is set. For habits with a target, the amount is added to the
+// day's total instead; a trailing number in the name ("Water 3") is read as
+// the amount. Unknown habits are an error unless opts.Create is set.
+func Mark(store storage.Storage, clock Clock, habitName string, opts MarkOptions) error {
 	// Validate input
 	habitName = strings.TrimSpace(habitName)
 	if habitName == "" {
 		return fmt.Errorf("habit name cannot be empty")
 	}
 
-	// Load existing habits
-	habits, err := store.Load()
+	now := clock.Now()
+	today := clock.Calendar.Day(now)
+	date, err := models.ResolveDate(opts.Date, today)
 	if err != nil {
-		return fmt.Errorf("failed to load habits: %w", err)
+		return err
 	}
+	backfill := date != today.Format(models.DateFormat)
 
-	today := time.Now()
-
-	// Check if habit exists
-	habit, index := habits.Find(habitName)
-	if habit != nil {
-		// Existing habit - update streak
-		err := habit.UpdateStreak(today)
-		if err != nil {
-			// Already marked today
-			fmt.Printf("✓ '%s' is already marked for today!\n", habitName)
-			return nil
+	// What happened is only reported once the change is saved, so a failed
+	// save is not preceded by a success message
+	var messages []string
+	say := func(format string, args ...any) {
+		messages = append(messages, fmt.Sprintf(format, args...))
+	}
+
+	err = store.Update(func(habits *models.HabitList) error {
+		messages = nil
+		// Check if habit exists
+		habit, _, err := habits.Resolve(habitName)
+		if err != nil && !errors.Is(err, models.ErrHabitNotFound) {
+			return err
+		}
+		if habit == nil && opts.Amount == 0 {
+			if name, amount, ok := splitAmount(habitName); ok {
+				if h, _, _ := habits.Resolve(name); h != nil && h.IsQuantitative() {
+					habit, opts.Amount = h, amount
+				}
+			}
+		}
+		if habit != nil {
+			habitName = habit.Name
 		}
 
-		// Update the habit in the list
-		habits[index] = *habit
-		fmt.Printf("✓ Marked '%s' as done today! Current streak: %d day(s)\n", habitName, habit.Streak)
-	} else {
-		// New habit - create and add
-		newHabit := models.Habit{
-			Name:     habitName,
-			LastDone: today.Format("2006-01-02"),
-			Streak:   1,
+		if habit != nil && habit.Archived {
+			return fmt.Errorf("habit '%s' is archived; restore it first with: habit unarchive %s", habitName, habitName)
 		}
 
-		if err := newHabit.Validate(); err != nil {
-			return fmt.Errorf("invalid habit: %w", err)
+		if habit != nil && habit.IsAvoid() {
+			// Avoid habit - record a relapse
+			if opts.Amount != 0 {
+				return fmt.Errorf("habit '%s' has no target; amounts cannot be logged", habitName)
+			}
+			if habit.HasEntry(date) {
+				if opts.Note == "" {
+					say("✓ A relapse of '%s' is already recorded for %s.\n", habitName, date)
+					return nil
+				}
+				if err := habit.AddNote(date, opts.Note); err != nil {
+					return fmt.Errorf("failed to add note: %w", err)
+				}
+				say("✓ Added note to the relapse of '%s' on %s.\n", habitName, date)
+				return nil
+			}
+
+			cleanBefore := habit.CleanDays(today)
+			if err := habit.AddCompletion(date, now); err != nil {
+				return fmt.Errorf("failed to record relapse: %w", err)
+			}
+			if err := habit.AddNote(date, opts.Note); err != nil {
+				return fmt.Errorf("failed to add note: %w", err)
+			}
+
+			say("✗ Relapse of '%s' recorded for %s (was clean for %d day(s)). Now clean for %d day(s).\n",
+				habitName, date, cleanBefore, habit.CleanDays(today))
+		} else if habit != nil && habit.IsQuantitative() {
+			// Measurable habit - add to the day's amount
+			amount := opts.Amount
+			if amount == 0 {
+				amount = 1
+			}
+			wasDone := habit.CompletedOn(date)
+			if err := habit.AddAmount(date, amount, now); err != nil {
+				return fmt.Errorf("failed to mark habit: %w", err)
+			}
+			if err := habit.AddNote(date, opts.Note); err != nil {
+				return fmt.Errorf("failed to add note: %w", err)
+			}
+
+			say("✓ Logged %s for '%s' on %s (%s)\n",
+				models.FormatAmount(amount), habitName, date, formatProgress(habit, date))
+			if habit.CompletedOn(date) && !wasDone {
+				say("🎯 Target reached! Current streak: %d %s(s)\n", habit.CurrentStreak(today), habit.Schedule.Unit())
+			}
+		} else if habit != nil {
+			// Existing habit - record completion and update streak
+			if opts.Amount != 0 {
+				return fmt.Errorf("habit '%s' has no target; set one with: habit target %s <amount> [unit]", habitName, habitName)
+			}
+
+			if habit.CompletedOn(date) {
+				if opts.Note != "" {
+					// Attach the note to the existing completion
+					if err := habit.AddNote(date, opts.Note); err != nil {
+						return fmt.Errorf("failed to add note: %w", err)
+					}
+					say("✓ Added note to '%s' for %s.\n", habitName, date)
+					return nil
+				}
+				if backfill {
+					return fmt.Errorf("habit '%s' is already marked for %s", habitName, date)
+				}
+				say("✓ '%s' is already marked for today!\n", habitName)
+				return nil
+			}
+
+			if err := habit.AddCompletion(date, now); err != nil {
+				return fmt.Errorf("failed to mark habit: %w", err)
+			}
+			if err := habit.AddNote(date, opts.Note); err != nil {
+				return fmt.Errorf("failed to add note: %w", err)
+			}
+
+			// Update the habit in the list
+			if backfill {
+				say("✓ Marked '%s' as done on %s! Current streak: %d %s(s)\n", habitName, date, habit.CurrentStreak(today), habit.Schedule.Unit())
+			} else {
+				say("✓ Marked '%s' as done today! Current streak: %d %s(s)\n", habitName, habit.CurrentStreak(today), habit.Schedule.Unit())
+			}
+		} else {
+			// New habit - create and add
+			if !opts.Create {
+				return fmt.Errorf("%w\n  To create it, use: habit add \"%s\" (or mark it with --create)",
+					notFoundError(*habits, habitName), habitName)
+			}
+			if opts.Amount != 0 {
+				return fmt.Errorf("habit '%s' not found; amounts can only be logged for habits with a target", habitName)
+			}
+
+			newHabit := models.Habit{Name: habitName}
+
+			if err := newHabit.AddCompletion(date, now); err != nil {
+				return fmt.Errorf("failed to mark habit: %w", err)
+			}
+			if err := newHabit.AddNote(date, opts.Note); err != nil {
+				return fmt.Errorf("failed to add note: %w", err)
+			}
+
+			if err := habits.Add(newHabit); err != nil {
+				return fmt.Errorf("invalid habit: %w", err)
+			}
+			if backfill {
+				say("✓ New habit '%s' added and marked for %s!\n", habitName, date)
+			} else {
+				say("✓ New habit '%s' added and marked for today!\n", habitName)
+			}
 		}
 
-		habits = append(habits, newHabit)
-		fmt.Printf("✓ New habit '%s' added and marked for today!\n", habitName)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Save updated habits
-	if err := store.Save(habits); err != nil {
-		return fmt.Errorf("failed to save habits: %w", err)
+	for _, message := range messages {
+		fmt.Print(message)
 	}
-
 	return nil
 }
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestMark_Backfill(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	today := time.Now()
+	twoDaysAgo := today.AddDate(0, 0, -2).Format(models.DateFormat)
+
+	// Marked two days ago and today, but forgot yesterday
+	habits := models.HabitList{
+		{Name: "Exercise", History: []models.Completion{
+			{Date: twoDaysAgo},
+			{Date: today.Format(models.DateFormat)},
+		}},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := Mark(store, Clock{}, "Exercise", MarkOptions{Date: "yesterday"}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load habits: %v", err)
+	}
+
+	exercise, _ := loaded.Find("Exercise")
+	if exercise == nil {
+		t.Fatal("Exercise habit not found")
+	}
+	if exercise.Streak != 3 {
+		t.Errorf("Exercise streak should be 3 after backfill, got %d", exercise.Streak)
+	}
+}
+
+func TestMark_BackfillDuplicate(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Exercise", History: []models.Completion{{Date: "2025-01-10"}}},
+	}
+	store.Save(habits)
+
+	err := Mark(store, Clock{}, "Exercise", MarkOptions{Date: "2025-01-10"})
+	if err == nil {
+		t.Error("Expected error for duplicate date, got nil")
+	}
+}
+
+func TestMark_FutureDate(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(models.DateFormat)
+	err := Mark(store, Clock{}, "Exercise", MarkOptions{Date: tomorrow})
+	if err == nil {
+		t.Error("Expected error for future date, got nil")
+	}
+}
+
+func TestUnmark(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Exercise", History: []models.Completion{
+			{Date: "2025-01-13"},
+			{Date: "2025-01-14"},
+			{Date: "2025-01-15"},
+		}},
+	}
+	store.Save(habits)
+
+	if err := Unmark(store, Clock{}, "Exercise", "2025-01-14"); err != nil {
+		t.Fatalf("Unmark failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	exercise, _ := loaded.Find("Exercise")
+	if exercise == nil {
+		t.Fatal("Exercise habit not found")
+	}
+	if len(exercise.History) != 2 {
+		t.Errorf("Expected 2 completions after unmark, got %d", len(exercise.History))
+	}
+	if exercise.Streak != 1 || exercise.LastDone != "2025-01-15" {
+		t.Errorf("Expected streak 1 ending 2025-01-15, got %d ending %s", exercise.Streak, exercise.LastDone)
+	}
+
+	// Unmarking a day without a completion is an error
+	if err := Unmark(store, Clock{}, "Exercise", "2025-01-14"); err == nil {
+		t.Error("Expected error for date without completion, got nil")
+	}
+}
+
+func TestMark_Amount(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Water", Target: 8, Unit: "glasses"},
+	}
+	store.Save(habits)
+
+	// Trailing number is read as the amount
+	if err := Mark(store, Clock{}, "Water 3", MarkOptions{}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+	if err := Mark(store, Clock{}, "Water", MarkOptions{Amount: 5}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	if len(loaded) != 1 {
+		t.Fatalf("Expected 1 habit, got %d", len(loaded))
+	}
+	today := time.Now().Format(models.DateFormat)
+	if got := loaded[0].AmountOn(today); got != 8 {
+		t.Errorf("Expected 8 glasses today, got %v", got)
+	}
+	if loaded[0].Streak != 1 {
+		t.Errorf("Expected streak 1 once the target is reached, got %d", loaded[0].Streak)
+	}
+}
+
+func TestMark_AvoidRecordsRelapse(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	if err := Avoid(store, Clock{}, "Smoking", "2025-01-01"); err != nil {
+		t.Fatalf("Avoid failed: %v", err)
+	}
+	if err := Mark(store, Clock{}, "Smoking", MarkOptions{Date: "yesterday"}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	smoking, _ := loaded.Find("Smoking")
+	if smoking == nil {
+		t.Fatal("Smoking habit not found")
+	}
+	if got := smoking.CleanDays(time.Now()); got != 1 {
+		t.Errorf("Expected 1 clean day after yesterday's relapse, got %d", got)
+	}
+
+	// Avoiding an existing habit is an error
+	if err := Avoid(store, Clock{}, "smoking", ""); err == nil {
+		t.Error("Expected error for existing habit, got nil")
+	}
+}
+
+func TestMark_Note(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	if err := Mark(store, Clock{}, "Run", MarkOptions{Note: "5k in 27min", Create: true}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+	// A second note on the same day is appended instead of being rejected
+	if err := Mark(store, Clock{}, "Run", MarkOptions{Note: "legs sore"}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	run, _ := loaded.Find("Run")
+	if run == nil || len(run.History) != 1 {
+		t.Fatal("Expected Run with a single completion")
+	}
+	if run.History[0].Note != "5k in 27min; legs sore" {
+		t.Errorf("Unexpected note: %q", run.History[0].Note)
+	}
+
+	if err := Log(store, "run"); err != nil {
+		t.Errorf("Log failed: %v", err)
+	}
+	if err := Search(store, Clock{}, "27min", SearchOptions{Notes: true}); err != nil {
+		t.Errorf("Search failed: %v", err)
+	}
+}
+
+// failingStorage runs updates but fails to save them.
+type failingStorage struct {
+	storage.Storage
+}
+
+func (s failingStorage) Update(fn func(*models.HabitList) error) error {
+	habits, _ := s.Load()
+	if err := fn(&habits); err != nil {
+		return err
+	}
+	return errors.New("disk full")
+}
+
+func TestMark_NoSuccessMessageWhenSaveFails(t *testing.T) {
+	store := storage.NewMemoryStorage("test")
+	store.Save(models.HabitList{{Name: "Exercise"}})
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := Mark(failingStorage{store}, Clock{}, "Exercise", MarkOptions{})
+	w.Close()
+	os.Stdout = stdout
+	output, _ := io.ReadAll(r)
+
+	if err == nil {
+		t.Fatal("Mark(, Clock{}) error = nil, want the save error")
+	}
+	if len(output) > 0 {
+		t.Errorf("Mark(, Clock{}) printed %q before failing", output)
+	}
+}
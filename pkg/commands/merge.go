@@ -0,0 +1,72 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Merge merges two data files, ours and theirs, that were both changed from
+// base, vacations included, and writes the result to outputPath, or over
+// ours if it is empty. Conflicts are listed and returned as an error after the result is written,
+// so git can use the command as a merge driver. The files are decrypted with
+// the store's passphrase if they are encrypted, and the result is encrypted
+// if the store is.
+func Merge(store storage.Storage, clock Clock, basePath, oursPath, theirsPath, outputPath string) error {
+	base, err := readDataFile(store, basePath)
+	if err != nil {
+		return err
+	}
+	ours, err := readDataFile(store, oursPath)
+	if err != nil {
+		return err
+	}
+	theirs, err := readDataFile(store, theirsPath)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts, err := storage.MergeData(base, ours, theirs)
+	if err != nil {
+		return fmt.Errorf("failed to merge habits: %w", err)
+	}
+
+	data, err := encodeData(store, merged, clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to encode habits: %w", err)
+	}
+	if outputPath == "" {
+		outputPath = oursPath
+	}
+	if err := storage.WriteFile(outputPath, data); err != nil {
+		return fmt.Errorf("failed to write merged habits: %w", err)
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Printf("✓ Merged %d habit(s) into %s\n", len(merged.Habits), outputPath)
+		return nil
+	}
+
+	fmt.Printf("⚠ Merged %d habit(s) into %s with %d conflict(s):\n", len(merged.Habits), outputPath, len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  - %s\n", c)
+	}
+	return fmt.Errorf("merge has %d conflict(s); check the habits listed above", len(conflicts))
+}
+
+// readDataFile reads the habits and vacations of a JSON data file,
+// decrypting them with the store's passphrase if needed. An empty file holds
+// no habits.
+func readDataFile(store storage.Storage, path string) (storage.Data, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return storage.Data{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	data, err := decodeData(store, content)
+	if err != nil {
+		return storage.Data{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+
+	write := func(name string, data storage.Data) string {
+		path := filepath.Join(tmpDir, name)
+		content, err := storage.Encode(data, time.Time{})
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	base := models.HabitList{{ID: "aaaa0001", Name: "Run", History: []models.Completion{{Date: "2025-01-01"}}}}
+	basePath := write("base.json", storage.Data{Habits: base})
+	oursPath := write("ours.json", storage.Data{Habits: models.HabitList{{ID: "aaaa0001", Name: "Run",
+		History: []models.Completion{{Date: "2025-01-01"}, {Date: "2025-01-02"}}}}})
+	theirsPath := write("theirs.json", storage.Data{Habits: models.HabitList{{ID: "aaaa0001", Name: "Run",
+		History: []models.Completion{{Date: "2025-01-01"}, {Date: "2025-01-03"}}}},
+		Vacations: models.Vacations{{Start: "2025-01-04", End: "2025-01-05"}}})
+
+	tests := []struct {
+		name       string
+		basePath   string
+		theirsPath string
+		output     string
+		wantErr    bool
+		wantDays   int
+	}{
+		{name: "merges into ours", basePath: basePath, theirsPath: theirsPath, wantDays: 3},
+		{name: "writes to output", basePath: basePath, theirsPath: theirsPath, output: filepath.Join(tmpDir, "merged.json"), wantDays: 3},
+		{name: "empty base", basePath: write("empty.json", storage.Data{}), theirsPath: theirsPath, wantDays: 3},
+		{name: "missing file", basePath: basePath, theirsPath: filepath.Join(tmpDir, "missing.json"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ours := write("ours-copy.json", mustDecode(t, oursPath))
+			err := Merge(store, Clock{}, tt.basePath, ours, tt.theirsPath, tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Merge(, Clock{}) error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			result := ours
+			if tt.output != "" {
+				result = tt.output
+			}
+			merged := mustDecode(t, result)
+			if len(merged.Habits) != 1 || len(merged.Habits[0].History) != tt.wantDays {
+				t.Errorf("merged habits = %+v, want 1 habit with %d completions", merged.Habits, tt.wantDays)
+			}
+			if len(merged.Vacations) != 1 {
+				t.Errorf("merged vacations = %+v, want theirs", merged.Vacations)
+			}
+			if _, err := os.Stat(result + ".bak"); !os.IsNotExist(err) {
+				t.Errorf("Merge(, Clock{}) left a backup of %s", result)
+			}
+		})
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+
+	paths := make(map[string]string)
+	for name, habitName := range map[string]string{"base": "Run", "ours": "Jog", "theirs": "Sprint"} {
+		data, _ := storage.Encode(storage.Data{Habits: models.HabitList{{ID: "aaaa0001", Name: habitName}}}, time.Time{})
+		paths[name] = filepath.Join(tmpDir, name+".json")
+		if err := os.WriteFile(paths[name], data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	if err := Merge(store, Clock{}, paths["base"], paths["ours"], paths["theirs"], ""); err == nil {
+		t.Fatal("Merge(, Clock{}) expected error for conflicting renames")
+	}
+
+	// The result is written anyway, keeping ours
+	merged := mustDecode(t, paths["ours"]).Habits
+	if len(merged) != 1 || merged[0].Name != "Jog" {
+		t.Errorf("merged habits = %+v, want Jog", merged)
+	}
+}
+
+// mustDecode reads the habits and vacations of a data file.
+func mustDecode(t *testing.T, path string) storage.Data {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	data, err := storage.Decode(content)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return data
+}
@@ -0,0 +1,57 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// MigrateStorage copies all habits and vacations from one storage to another,
+// for example from the JSON file to a SQLite database. The source is left untouched. A
+// target that already holds habits is only overwritten when force is set.
+func MigrateStorage(from, to storage.Storage, force bool) error {
+	if from.GetPath() == to.GetPath() {
+		return fmt.Errorf("source and target are the same file: %s", from.GetPath())
+	}
+
+	data, err := from.LoadData()
+	if err != nil {
+		return fmt.Errorf("failed to load habits: %w", err)
+	}
+
+	if to.Exists() && !force {
+		existing, err := to.LoadData()
+		if err != nil {
+			return fmt.Errorf("failed to read target: %w", err)
+		}
+		if len(existing.Habits) > 0 {
+			return fmt.Errorf("target %s already has %d habit(s); use --force to overwrite it", to.GetPath(), len(existing.Habits))
+		}
+	}
+
+	if err := to.SaveData(data); err != nil {
+		return fmt.Errorf("failed to save habits to target: %w", err)
+	}
+
+	// Read the copy back to make sure nothing was lost on the way
+	copied, err := to.LoadData()
+	if err != nil {
+		return fmt.Errorf("failed to verify target: %w", err)
+	}
+	habits := data.Habits
+	if len(copied.Habits) != len(habits) {
+		return fmt.Errorf("target has %d habit(s) after migration, expected %d", len(copied.Habits), len(habits))
+	}
+	for i := range habits {
+		if len(copied.Habits[i].History) != len(habits[i].History) {
+			return fmt.Errorf("history of '%s' was not copied completely", habits[i].Name)
+		}
+	}
+	if len(copied.Vacations) != len(data.Vacations) {
+		return fmt.Errorf("target has %d vacation(s) after migration, expected %d", len(copied.Vacations), len(data.Vacations))
+	}
+
+	fmt.Printf("✓ Copied %d habit(s) from %s to %s\n", len(habits), from.GetPath(), to.GetPath())
+	return nil
+}
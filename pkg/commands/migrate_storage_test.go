@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	tmpDir := t.TempDir()
+	jsonStore := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+	sqliteStore := storage.NewSQLiteStorage(filepath.Join(tmpDir, "habits.db"), storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Exercise", History: []models.Completion{{Date: "2025-01-14"}, {Date: "2025-01-15", Note: "5k"}}},
+		{Name: "Reading"},
+	}
+	vacations := models.Vacations{{Start: "2025-01-10", End: "2025-01-12"}}
+	if err := jsonStore.SaveData(storage.Data{Habits: habits, Vacations: vacations}); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := MigrateStorage(jsonStore, sqliteStore, false); err != nil {
+		t.Fatalf("MigrateStorage to SQLite failed: %v", err)
+	}
+	loaded, err := sqliteStore.Load()
+	if err != nil {
+		t.Fatalf("Failed to load SQLite storage: %v", err)
+	}
+	if len(loaded) != 2 || len(loaded[0].History) != 2 || loaded[0].History[1].Note != "5k" {
+		t.Errorf("Unexpected habits after migration: %+v", loaded)
+	}
+	if data, _ := sqliteStore.LoadData(); len(data.Vacations) != 1 || data.Vacations[0] != vacations[0] {
+		t.Errorf("Unexpected vacations after migration: %+v", data.Vacations)
+	}
+
+	// Copying back needs --force because the JSON file has habits
+	if err := MigrateStorage(sqliteStore, jsonStore, false); err == nil {
+		t.Error("Expected error when the target already has habits")
+	}
+	if err := MigrateStorage(sqliteStore, jsonStore, true); err != nil {
+		t.Errorf("MigrateStorage with force failed: %v", err)
+	}
+	if err := MigrateStorage(jsonStore, jsonStore, true); err == nil {
+		t.Error("Expected error when source and target are the same")
+	}
+}
@@ -0,0 +1,93 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Pause pauses a habit from today through until ("tomorrow" or YYYY-MM-DD),
+// or until it is resumed if until is empty. Paused days do not break the
+// streak.
+func Pause(store storage.Storage, clock Clock, habitName, until string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	today := clock.Today()
+	todayStr := today.Format(models.DateFormat)
+	if until != "" {
+		var err error
+		if until, err = models.ParseDate(until, today); err != nil {
+			return err
+		}
+		if until < todayStr {
+			return fmt.Errorf("date %s is in the past", until)
+		}
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		return habit.Pause(todayStr, until)
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	if until == "" {
+		fmt.Printf("⏸  Paused '%s'. Resume it with: habit resume %s\n", habitName, habitName)
+	} else {
+		fmt.Printf("⏸  Paused '%s' through %s. Your streak is kept.\n", habitName, until)
+	}
+	return nil
+}
+
+// Resume ends the current pause of a habit so it is due again from today.
+func Resume(store storage.Storage, clock Clock, habitName string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	today := clock.Today()
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		return habit.Resume(today)
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	fmt.Printf("▶  Resumed '%s'. Current streak: %d %s(s)\n", habitName, habit.CurrentStreak(today), habit.Schedule.Unit())
+	return nil
+}
+
+// breakStatus shows whether a habit is on a break today and how many freeze
+// tokens it has left, e.g. " | Paused until 2025-02-01 | 2 freeze(s)".
+func breakStatus(h *models.Habit, today time.Time) string {
+	status := ""
+	if b, ok := h.BreakOn(today.Format(models.DateFormat)); ok {
+		switch b.Reason {
+		case models.BreakVacation:
+			status = " | On vacation"
+		case models.BreakFreeze:
+			status = " | Frozen"
+		default:
+			status = " | Paused"
+		}
+		if b.End != "" && b.Reason != models.BreakFreeze {
+			status += " until " + b.End
+		}
+	}
+	if h.Freezes > 0 {
+		status += fmt.Sprintf(" | %d freeze(s)", h.Freezes)
+	}
+	return status
+}
@@ -5,40 +5,29 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Reset resets a habit's streak to zero.
-func Reset(store storage.Storage, habitName string) error {
+// Reset resets a habit's streak to zero. The completion history is kept.
+func Reset(store storage.Storage, clock Clock, habitName string) error {
 	// Validate input
 	habitName = strings.TrimSpace(habitName)
 	if habitName == "" {
 		return fmt.Errorf("habit name cannot be empty")
 	}
 
-	// Load existing habits
-	habits, err := store.Load()
+	today := clock.Today()
+	var oldStreak int
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		oldStreak = habit.CurrentStreak(today)
+		return habit.ResetStreak(clock.Now(), today)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load habits: %w", err)
+		return err
 	}
+	habitName = habit.Name
 
-	// Find the habit
-	habit, index := habits.Find(habitName)
-	if habit == nil {
-		return fmt.Errorf("habit '%s' not found", habitName)
-	}
-
-	// Reset the streak
-	oldStreak := habit.Streak
-	habit.Streak = 0
-	habit.LastDone = ""
-	habits[index] = *habit
-
-	// Save updated habits
-	if err := store.Save(habits); err != nil {
-		return fmt.Errorf("failed to save habits: %w", err)
-	}
-
-	fmt.Printf("✓ Habit '%s' has been reset (previous streak: %d day(s)).\n", habitName, oldStreak)
+	fmt.Printf("✓ Habit '%s' has been reset (previous streak: %d %s(s)).\n", habitName, oldStreak, habit.Schedule.Unit())
 	return nil
 }
@@ -0,0 +1,45 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// SetSchedule changes how often a habit is due and recalculates its streak.
+func SetSchedule(store storage.Storage, clock Clock, habitName, spec string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	schedule, err := models.ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		if habit.IsAvoid() && !schedule.IsDaily() {
+			return fmt.Errorf("avoid habits cannot have a schedule")
+		}
+
+		// Apply the schedule and recount the streak
+		habit.Schedule = schedule
+		if err := habit.Recalculate(); err != nil {
+			return fmt.Errorf("failed to recalculate streak: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	fmt.Printf("✓ '%s' is now scheduled %s. Current streak: %d %s(s)\n",
+		habitName, schedule, habit.CurrentStreak(clock.Today()), schedule.Unit())
+	return nil
+}
@@ -8,8 +8,14 @@ import (
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
+// SearchOptions holds optional settings for the search command.
+type SearchOptions struct {
+	Notes bool   // Also match the notes attached to completions
+	Tag   string // Only search habits with this tag
+}
+
 // Search searches for habits matching a query string.
-func Search(store storage.Storage, query string) error {
+func Search(store storage.Storage, clock Clock, query string, opts SearchOptions) error {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return fmt.Errorf("search query cannot be empty")
@@ -24,15 +30,28 @@ func Search(store storage.Storage, query string) error {
 		fmt.Println("No habits tracked.")
 		return nil
 	}
+	habits = habits.FilterByTag(opts.Tag)
 
 	// Search for matching habits (case-insensitive substring match)
+	today := clock.Today()
 	queryLower := strings.ToLower(query)
 	var matches []string
 	matchCount := 0
 
 	for _, habit := range habits {
-		if strings.Contains(strings.ToLower(habit.Name), queryLower) {
-			matches = append(matches, habit.String())
+		nameMatch := strings.Contains(strings.ToLower(habit.Name), queryLower)
+
+		// Collect completions whose notes match
+		var noteMatches []string
+		if opts.Notes {
+			for _, c := range habit.NotesMatching(query) {
+				noteMatches = append(noteMatches, "  "+formatLogEntry(&habit, c))
+			}
+		}
+
+		if nameMatch || len(noteMatches) > 0 {
+			matches = append(matches, habit.Summary(today))
+			matches = append(matches, noteMatches...)
 			matchCount++
 		}
 	}
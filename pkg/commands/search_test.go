@@ -11,7 +11,7 @@ import (
 func TestSearch(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	habits := models.HabitList{
 		{Name: "Morning Exercise", LastDone: "2025-01-15", Streak: 5},
@@ -21,13 +21,13 @@ func TestSearch(t *testing.T) {
 	store.Save(habits)
 
 	// Test case-insensitive search
-	err := Search(store, "exercise")
+	err := Search(store, Clock{}, "exercise", SearchOptions{})
 	if err != nil {
 		t.Errorf("Search failed: %v", err)
 	}
 
 	// Test partial match
-	err = Search(store, "read")
+	err = Search(store, Clock{}, "read", SearchOptions{})
 	if err != nil {
 		t.Errorf("Search failed: %v", err)
 	}
@@ -36,9 +36,9 @@ func TestSearch(t *testing.T) {
 func TestSearch_EmptyQuery(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
-	err := Search(store, "")
+	err := Search(store, Clock{}, "", SearchOptions{})
 	if err == nil {
 		t.Error("Expected error for empty query, got nil")
 	}
@@ -47,7 +47,7 @@ func TestSearch_EmptyQuery(t *testing.T) {
 func TestSearch_NoResults(t *testing.T) {
 	tmpDir := t.TempDir()
 	storePath := filepath.Join(tmpDir, "habits.json")
-	store := storage.NewJSONStorage(storePath)
+	store := storage.NewJSONStorage(storePath, storage.Options{})
 
 	habits := models.HabitList{
 		{Name: "Exercise", LastDone: "2025-01-15", Streak: 5},
@@ -55,7 +55,7 @@ func TestSearch_NoResults(t *testing.T) {
 	store.Save(habits)
 
 	// Should not error, just show no results
-	err := Search(store, "nonexistent")
+	err := Search(store, Clock{}, "nonexistent", SearchOptions{})
 	if err != nil {
 		t.Errorf("Search should not error on no results: %v", err)
 	}
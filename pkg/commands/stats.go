@@ -4,30 +4,97 @@ package commands
 import (
 	"fmt"
 
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
 )
 
-// Stats displays statistics about all habits.
-func Stats(store storage.Storage) error {
-	habits, err := store.Load()
+// StatsOptions holds optional settings for the stats command.
+type StatsOptions struct {
+	Tag string // Only include habits with this tag
+}
+
+// Stats displays statistics about all habits, or about the habits with
+// opts.Tag when it is set.
+func Stats(store storage.Storage, clock Clock, opts StatsOptions) error {
+	data, err := store.LoadData()
 	if err != nil {
 		return fmt.Errorf("failed to load habits: %w", err)
 	}
+	habits := data.Habits
+	habits.SetVacations(data.Vacations)
 
 	if len(habits) == 0 {
 		fmt.Println("No habits tracked yet.")
 		return nil
 	}
 
-	stats := habits.Stats()
+	habits = habits.FilterByTag(opts.Tag)
+	if len(habits) == 0 {
+		fmt.Printf("No habits tagged '%s'.\n", models.NormalizeTag(opts.Tag))
+		return nil
+	}
+
+	// Archived habits are left out of the statistics
+	habits = habits.Active()
+	if len(habits) == 0 {
+		fmt.Println("No active habits.")
+		return nil
+	}
+
+	today := clock.Today()
+	stats := habits.Stats(today)
 
-	fmt.Println("📊 Habit Statistics:")
+	if opts.Tag != "" {
+		fmt.Printf("📊 Habit Statistics (tag: %s):\n", models.NormalizeTag(opts.Tag))
+	} else {
+		fmt.Println("📊 Habit Statistics:")
+	}
+	if vacation, ok := data.Vacations.On(today.Format(models.DateFormat)); ok {
+		fmt.Println("  " + vacationSummary(vacation))
+	}
 	fmt.Println()
 	fmt.Printf("  Total habits:       %d\n", stats["total"])
 	fmt.Printf("  Marked today:       %d\n", stats["marked_today"])
-	fmt.Printf("  Longest streak:     %d day(s)\n", stats["max_streak"])
-	fmt.Printf("  Total streak days:  %d\n", stats["total_streak"])
-	fmt.Printf("  Average streak:     %.1f day(s)\n", stats["avg_streak"])
+	fmt.Printf("  Still due today:    %d\n", stats["due_today"])
+	if n, _ := stats["on_break"].(int); n > 0 {
+		fmt.Printf("  On a break today:   %d\n", stats["on_break"])
+	}
+	for _, unit := range models.StreakUnits {
+		if n, _ := stats[models.StreakKey("habits", unit)].(int); n == 0 {
+			continue
+		}
+		// Streaks of different units are never added up
+		label := ""
+		switch unit {
+		case "completion":
+			label = "interval "
+		case "week", "month":
+			label = unit + "ly "
+		}
+		fmt.Printf("  %-19s %d %s(s)\n", "Longest "+label+"streak:", stats[models.StreakKey("max", unit)], unit)
+		fmt.Printf("  %-19s %d\n", "Total streak "+unit+"s:", stats[models.StreakKey("total", unit)])
+		fmt.Printf("  %-19s %.1f %s(s)\n", "Average "+label+"streak:", stats[models.StreakKey("avg", unit)], unit)
+	}
+
+	// Progress of habits that are not plain daily yes/no habits
+	printedHeader := false
+	for _, h := range habits {
+		if h.Schedule.IsDaily() && !h.IsQuantitative() && !h.IsAvoid() {
+			continue
+		}
+		if !printedHeader {
+			fmt.Println()
+			fmt.Println("  Progress:")
+			printedHeader = true
+		}
+		if h.IsAvoid() {
+			fmt.Printf("    %s (avoid): clean for %d day(s)\n", h.Name, h.CleanDays(today))
+			continue
+		}
+		fmt.Printf("    %s (%s): streak %d %s(s)%s%s%s\n",
+			h.Name, h.Schedule, h.CurrentStreak(today), h.Schedule.Unit(),
+			scheduleStatus(&h, today), targetStatus(&h, today), breakStatus(&h, today))
+	}
 
 	return nil
 }
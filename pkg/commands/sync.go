@@ -0,0 +1,247 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/remote"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// maxSyncAttempts is how often Sync merges and pushes again when another
+// client pushed in between.
+const maxSyncAttempts = 3
+
+// syncState is what Sync remembers between runs: the server it last synced
+// with, the last revision merged into the local habits, and the habits and
+// vacations of that revision, which are the base for merging the next time.
+type syncState struct {
+	Server   string          `json:"server"`
+	Revision int64           `json:"revision"`
+	Base     json.RawMessage `json:"base,omitempty"` // A JSON data file, encrypted if the habits are
+}
+
+// Sync syncs the habits and vacations in store with a sync server. Changes
+// made on the server since the last sync are merged into the local ones like
+// `habit merge` does, and the result is pushed back. If another client
+// pushed in between, Sync merges again. statePath is where the last synced
+// version is kept; if empty, every sync merges as if it were the first.
+func Sync(store storage.Storage, clock Clock, client *remote.Client, statePath string) error {
+	state, base, err := loadSyncState(store, statePath, client.URL)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	var conflicts []models.MergeConflict
+	for attempt := 1; ; attempt++ {
+		snapshot, err := client.Pull()
+		if err != nil {
+			return fmt.Errorf("failed to pull habits: %w", err)
+		}
+
+		var local storage.Data
+		pulled := state == nil || snapshot.Revision != state.Revision
+		changed = changed || pulled && !sameData(base, snapshot.Data())
+		if pulled {
+			err = store.UpdateData(func(data *storage.Data) error {
+				merged, c, err := storage.MergeData(base, *data, snapshot.Data())
+				if err != nil {
+					return err
+				}
+				*data, local, conflicts = merged, merged, append(conflicts, c...)
+				return nil
+			})
+		} else {
+			local, err = store.LoadData()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to merge habits from the server: %w", err)
+		}
+
+		// The local habits now include this snapshot, so it is the base from
+		// here on: merging it again would count amounts and freezes twice
+		// if the push fails or another client pushed in between
+		if pulled {
+			if err := saveSyncState(store, statePath, client.URL, snapshot, clock.Now()); err != nil {
+				return err
+			}
+			state, base = &syncState{Server: client.URL, Revision: snapshot.Revision}, snapshot.Data()
+		}
+
+		result, pushed := snapshot, !sameData(local, snapshot.Data())
+		if pushed {
+			result, err = client.Push(snapshot.Revision, local)
+			if errors.Is(err, remote.ErrConflict) && attempt < maxSyncAttempts {
+				continue // Someone else synced in between
+			}
+			if err != nil {
+				return fmt.Errorf("failed to push habits: %w", err)
+			}
+		}
+
+		if err := saveSyncState(store, statePath, client.URL, result, clock.Now()); err != nil {
+			return err
+		}
+		printSyncResult(client.URL, result, changed, pushed, conflicts)
+		return nil
+	}
+}
+
+// SyncServer serves the habits in store to `habit sync` clients at addr
+// until interrupted. If token is set, clients must send it. Without a token,
+// only addresses on the loopback interface are served unless insecure is set.
+func SyncServer(store storage.Storage, addr, token string, insecure bool) error {
+	listener, err := listenSync(addr, token, insecure)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           remote.NewServer(store, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	fmt.Printf("🔄 Serving habits from %s at http://%s\n", store.GetPath(), listener.Addr())
+	if token == "" && !isLoopback(listener.Addr()) {
+		fmt.Println("⚠ No HABIT_SYNC_TOKEN is set: anyone who can reach this address can read and change the habits.")
+	}
+	fmt.Println("Press Ctrl+C to stop.")
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("sync server failed: %w", err)
+	}
+	fmt.Println("\n✓ Sync server stopped")
+	return nil
+}
+
+// listenSync listens on addr for SyncServer. An address other machines can
+// reach is refused without a token, unless insecure is set.
+func listenSync(addr, token string, insecure bool) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if token == "" && !insecure && !isLoopback(listener.Addr()) {
+		listener.Close()
+		return nil, fmt.Errorf("refusing to serve habits on %s without a token: set HABIT_SYNC_TOKEN, "+
+			"listen on 127.0.0.1 only, or pass --insecure to let anyone who can reach it read and change them", addr)
+	}
+	return listener, nil
+}
+
+// isLoopback reports whether a listening address only accepts connections
+// from this machine.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// loadSyncState reads the state of the last sync with server. Without one,
+// the base is empty, so the first sync keeps the habits of both sides.
+func loadSyncState(store storage.Storage, statePath, server string) (*syncState, storage.Data, error) {
+	if statePath == "" {
+		return nil, storage.Data{}, nil
+	}
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return nil, storage.Data{}, nil
+	}
+	if err != nil {
+		return nil, storage.Data{}, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, storage.Data{}, fmt.Errorf("invalid sync state in %s: %w", statePath, err)
+	}
+	if state.Server != server {
+		return nil, storage.Data{}, nil
+	}
+	base, err := decodeData(store, state.Base)
+	if err != nil {
+		return nil, storage.Data{}, fmt.Errorf("invalid sync state in %s: %w", statePath, err)
+	}
+	return &state, base, nil
+}
+
+// saveSyncState remembers the snapshot last synced with server.
+func saveSyncState(store storage.Storage, statePath, server string, snapshot remote.Snapshot, now time.Time) error {
+	if statePath == "" {
+		return nil
+	}
+	base, err := encodeData(store, snapshot.Data(), now)
+	if err != nil {
+		return fmt.Errorf("failed to encode habits: %w", err)
+	}
+	data, err := json.MarshalIndent(syncState{Server: server, Revision: snapshot.Revision, Base: base}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create sync state directory: %w", err)
+	}
+	if err := storage.WriteFile(statePath, data); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
+
+// printSyncResult reports what a sync did.
+func printSyncResult(server string, result remote.Snapshot, pulled, pushed bool, conflicts []models.MergeConflict) {
+	fmt.Printf("✓ Synced %d habit(s) with %s (revision %d)\n", len(result.Habits), server, result.Revision)
+	switch {
+	case pulled && pushed:
+		fmt.Println("  Merged changes from the server and sent local changes")
+	case pulled:
+		fmt.Println("  Received changes from the server")
+	case pushed:
+		fmt.Println("  Sent local changes")
+	default:
+		fmt.Println("  Already up to date")
+	}
+
+	if len(conflicts) > 0 {
+		fmt.Printf("⚠ %d conflict(s) were resolved in favor of this machine (ours; theirs is the server):\n", len(conflicts))
+		for _, c := range conflicts {
+			fmt.Printf("  - %s\n", c)
+		}
+	}
+}
+
+// sameData reports whether two versions of the habits and vacations are
+// identical.
+func sameData(a, b storage.Data) bool {
+	return sameList(a.Habits, b.Habits) && sameList(a.Vacations, b.Vacations)
+}
+
+// sameList reports whether two lists are stored the same way, empty or not.
+func sameList[T any](a, b []T) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
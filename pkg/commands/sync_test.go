@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/remote"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestSync_TwoMachines(t *testing.T) {
+	tmpDir := t.TempDir()
+	server := httptest.NewServer(remote.NewServer(storage.NewJSONStorage(filepath.Join(tmpDir, "server.json"), storage.Options{}), "token"))
+	defer server.Close()
+	client := remote.NewClient(server.URL, "token")
+
+	laptop := storage.NewJSONStorage(filepath.Join(tmpDir, "laptop.json"), storage.Options{})
+	laptopState := filepath.Join(tmpDir, "laptop.sync")
+	desktop := storage.NewJSONStorage(filepath.Join(tmpDir, "desktop.json"), storage.Options{})
+	desktopState := filepath.Join(tmpDir, "desktop.sync")
+
+	sync := func(store storage.Storage, state string) {
+		t.Helper()
+		if err := Sync(store, Clock{}, client, state); err != nil {
+			t.Fatalf("Sync(, Clock{}) error = %v", err)
+		}
+	}
+	dates := func(store storage.Storage, name string) int {
+		t.Helper()
+		habits, _ := store.Load()
+		habit, _ := habits.Find(name)
+		if habit == nil {
+			t.Fatalf("habit %q missing from %s", name, store.GetPath())
+		}
+		return len(habit.History)
+	}
+
+	laptop.Save(models.HabitList{{ID: "aaaa0001", Name: "Run", History: []models.Completion{{Date: "2025-01-01"}}}})
+	sync(laptop, laptopState)
+	sync(desktop, desktopState)
+	if got := dates(desktop, "Run"); got != 1 {
+		t.Fatalf("desktop has %d completions after first sync, want 1", got)
+	}
+
+	// Both machines mark different days before syncing again
+	laptop.Update(func(hl *models.HabitList) error {
+		return (*hl)[0].AddCompletion("2025-01-02", time.Now())
+	})
+	desktop.Update(func(hl *models.HabitList) error {
+		(*hl)[0].AddCompletion("2025-01-03", time.Now())
+		return hl.Add(models.Habit{Name: "Read"})
+	})
+	if err := StartVacation(desktop, Clock{}, "2025-02-01", "2025-02-07"); err != nil {
+		t.Fatalf("StartVacation() error = %v", err)
+	}
+	sync(laptop, laptopState)
+	sync(desktop, desktopState)
+	sync(laptop, laptopState)
+
+	for _, store := range []storage.Storage{laptop, desktop} {
+		if got := dates(store, "Run"); got != 3 {
+			t.Errorf("%s has %d completions, want 3", store.GetPath(), got)
+		}
+		dates(store, "Read")
+		if data, _ := store.LoadData(); len(data.Vacations) != 1 {
+			t.Errorf("%s has vacations %+v, want the desktop's", store.GetPath(), data.Vacations)
+		}
+	}
+
+	// Unmarking on one machine is not undone by the other
+	laptop.Update(func(hl *models.HabitList) error {
+		return (*hl)[0].RemoveCompletion("2025-01-01")
+	})
+	sync(laptop, laptopState)
+	sync(desktop, desktopState)
+	if got := dates(desktop, "Run"); got != 2 {
+		t.Errorf("desktop has %d completions after unmark, want 2", got)
+	}
+}
+
+func TestSync_Conflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	serverStore := storage.NewMemoryStorage("server")
+	server := httptest.NewServer(remote.NewServer(serverStore, ""))
+	defer server.Close()
+	client := remote.NewClient(server.URL, "")
+
+	local := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+	state := filepath.Join(tmpDir, "habits.sync")
+	local.Save(models.HabitList{{ID: "aaaa0001", Name: "Run"}})
+	if err := Sync(local, Clock{}, client, state); err != nil {
+		t.Fatalf("Sync(, Clock{}) error = %v", err)
+	}
+
+	// Renamed differently on the server and locally: ours is kept everywhere
+	serverStore.Save(models.HabitList{{ID: "aaaa0001", Name: "Sprint"}})
+	local.Save(models.HabitList{{ID: "aaaa0001", Name: "Jog"}})
+	if err := Sync(local, Clock{}, client, state); err != nil {
+		t.Fatalf("Sync(, Clock{}) error = %v", err)
+	}
+
+	for _, store := range []storage.Storage{local, serverStore} {
+		habits, _ := store.Load()
+		if len(habits) != 1 || habits[0].Name != "Jog" {
+			t.Errorf("%s habits = %+v, want Jog", store.GetPath(), habits)
+		}
+	}
+}
+
+func TestSync_RetriesWithoutCountingTwice(t *testing.T) {
+	tests := []struct {
+		name string
+		// beforePush runs before the first push reaches the server; if it
+		// returns false, the push fails and Sync is run again
+		beforePush func(server storage.Storage) bool
+		wantHabits int
+	}{
+		{
+			name: "another client pushed in between",
+			beforePush: func(server storage.Storage) bool {
+				server.Update(func(hl *models.HabitList) error {
+					return hl.Add(models.Habit{ID: "aaaa0002", Name: "Read"})
+				})
+				return true
+			},
+			wantHabits: 2,
+		},
+		{
+			name:       "push failed and sync run again",
+			beforePush: func(server storage.Storage) bool { return false },
+			wantHabits: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			water := func(amount float64) models.HabitList {
+				return models.HabitList{{ID: "aaaa0001", Name: "Water", Target: 8,
+					History: []models.Completion{{Date: "2025-01-01", Amount: amount}}}}
+			}
+			serverStore := storage.NewMemoryStorage("server")
+			serverStore.Save(water(2))
+			handler := remote.NewServer(serverStore, "")
+			var pushes int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut {
+					if pushes++; pushes == 1 && !tt.beforePush(serverStore) {
+						http.Error(w, `{"error":"disk full"}`, http.StatusInternalServerError)
+						return
+					}
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+			client := remote.NewClient(server.URL, "")
+
+			local := storage.NewJSONStorage(filepath.Join(tmpDir, "habits.json"), storage.Options{})
+			state := filepath.Join(tmpDir, "habits.sync")
+			local.Save(water(2))
+			if err := Sync(local, Clock{}, client, state); err != nil {
+				t.Fatalf("first Sync(, Clock{}) error = %v", err)
+			}
+			pushes = 0
+
+			// 1 more here and 2 more on the server: 5 in all
+			local.Save(water(3))
+			serverStore.Save(water(4))
+			if err := Sync(local, Clock{}, client, state); err != nil {
+				if err := Sync(local, Clock{}, client, state); err != nil {
+					t.Fatalf("Sync(, Clock{}) run again error = %v", err)
+				}
+			}
+
+			for _, store := range []storage.Storage{local, serverStore} {
+				habits, _ := store.Load()
+				if len(habits) != tt.wantHabits {
+					t.Errorf("%s has %d habits, want %d", store.GetPath(), len(habits), tt.wantHabits)
+				}
+				water, _ := habits.Find("Water")
+				if got := water.History[0].Amount; got != 5 {
+					t.Errorf("%s Water amount = %v, want 5", store.GetPath(), got)
+				}
+			}
+		})
+	}
+}
+
+func TestListenSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		token    string
+		insecure bool
+		wantErr  bool
+	}{
+		{name: "loopback without token", addr: "127.0.0.1:0"},
+		{name: "all interfaces without token", addr: ":0", wantErr: true},
+		{name: "all interfaces with token", addr: ":0", token: "secret"},
+		{name: "all interfaces insecure", addr: "0.0.0.0:0", insecure: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := listenSync(tt.addr, tt.token, tt.insecure)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listenSync(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			}
+			if listener != nil {
+				listener.Close()
+			}
+		})
+	}
+}
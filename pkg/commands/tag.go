@@ -0,0 +1,78 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// AddTag adds a tag to a habit.
+func AddTag(store storage.Storage, habitName, tag string) error {
+	return updateTag(store, habitName, tag, true)
+}
+
+// RemoveTag removes a tag from a habit.
+func RemoveTag(store storage.Storage, habitName, tag string) error {
+	return updateTag(store, habitName, tag, false)
+}
+
+func updateTag(store storage.Storage, habitName, tag string, add bool) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+	if strings.TrimSpace(tag) == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		if add {
+			return habit.AddTag(tag)
+		}
+		return habit.RemoveTag(tag)
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	if add {
+		fmt.Printf("✓ Tagged '%s' with '%s'\n", habitName, models.NormalizeTag(tag))
+	} else {
+		fmt.Printf("✓ Removed tag '%s' from '%s'\n", models.NormalizeTag(tag), habitName)
+	}
+	return nil
+}
+
+// ListTags displays every tag in use and how many habits carry it.
+func ListTags(store storage.Storage) error {
+	habits, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load habits: %w", err)
+	}
+
+	counts := habits.Tags()
+	if len(counts) == 0 {
+		fmt.Println("No tags in use.")
+		fmt.Println("\nTo tag a habit, use:")
+		fmt.Println("  habit tag add <habit-name> <tag>")
+		return nil
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	fmt.Printf("🏷  %d tag(s):\n\n", len(tags))
+	for _, tag := range tags {
+		fmt.Printf("- %s (%d habit(s))\n", tag, counts[tag])
+	}
+	return nil
+}
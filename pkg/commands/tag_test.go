@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestTag(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	habits := models.HabitList{
+		{Name: "Running"},
+		{Name: "Reading"},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := AddTag(store, "running", "Health"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := AddTag(store, "Running", "health"); err == nil {
+		t.Error("Expected error when adding a duplicate tag")
+	}
+	if err := AddTag(store, "Swimming", "health"); err == nil {
+		t.Error("Expected error for non-existent habit")
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load habits: %v", err)
+	}
+	running, _ := loaded.Find("Running")
+	if !running.HasTag("health") {
+		t.Errorf("Expected Running to be tagged health, got %v", running.Tags)
+	}
+
+	// Filters accept the tag
+	if err := List(store, Clock{}, ListOptions{Tag: "health"}); err != nil {
+		t.Errorf("List with tag failed: %v", err)
+	}
+	if err := Stats(store, Clock{}, StatsOptions{Tag: "health"}); err != nil {
+		t.Errorf("Stats with tag failed: %v", err)
+	}
+	if err := Export(store, Clock{}, "csv", filepath.Join(tmpDir, "work.csv"), ExportOptions{Tag: "work"}); err == nil {
+		t.Error("Expected error when exporting a tag with no habits")
+	}
+
+	if err := RemoveTag(store, "Running", "health"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	loaded, err = store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load habits: %v", err)
+	}
+	running, _ = loaded.Find("Running")
+	if len(running.Tags) != 0 {
+		t.Errorf("Expected no tags after removal, got %v", running.Tags)
+	}
+}
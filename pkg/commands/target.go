@@ -0,0 +1,64 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// SetTarget sets the daily target and unit of a measurable habit. A target
+// of zero turns it back into a yes/no habit.
+func SetTarget(store storage.Storage, habitName string, target float64, unit string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+	if target < 0 {
+		return fmt.Errorf("target cannot be negative")
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		if err := habit.SetTarget(target, unit); err != nil {
+			return fmt.Errorf("failed to set target: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	if target == 0 {
+		fmt.Printf("✓ Removed the target from '%s'\n", habitName)
+	} else {
+		fmt.Printf("✓ '%s' now has a target of %s\n", habitName, habit.TargetString())
+	}
+	return nil
+}
+
+// splitAmount splits a trailing number off a habit name, so that
+// "Water 3" becomes "Water" and 3.
+func splitAmount(input string) (string, float64, bool) {
+	idx := strings.LastIndex(input, " ")
+	if idx < 0 {
+		return "", 0, false
+	}
+	amount, err := models.ParseAmount(input[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.TrimSpace(input[:idx]), amount, true
+}
+
+// formatProgress describes the amount logged against the target, e.g. "5/8 glasses".
+func formatProgress(h *models.Habit, date string) string {
+	progress := models.FormatAmount(h.AmountOn(date)) + "/" + models.FormatAmount(h.Target)
+	if h.Unit != "" {
+		progress += " " + h.Unit
+	}
+	return progress
+}
@@ -0,0 +1,43 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// Unmark removes a single completion from a habit and recalculates its
+// streak. The date defaults to today.
+func Unmark(store storage.Storage, clock Clock, habitName, date string) error {
+	// Validate input
+	habitName = strings.TrimSpace(habitName)
+	if habitName == "" {
+		return fmt.Errorf("habit name cannot be empty")
+	}
+
+	date, err := models.ResolveDate(date, clock.Today())
+	if err != nil {
+		return err
+	}
+
+	habit, err := updateHabit(store, habitName, func(habit *models.Habit) error {
+		// Remove the completion
+		if !habit.HasEntry(date) {
+			return fmt.Errorf("habit '%s' is not marked for %s", habit.Name, date)
+		}
+		if err := habit.RemoveCompletion(date); err != nil {
+			return fmt.Errorf("failed to unmark habit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	habitName = habit.Name
+
+	fmt.Printf("✓ Unmarked '%s' for %s. Current streak: %d %s(s)\n", habitName, date, habit.CurrentStreak(clock.Today()), habit.Schedule.Unit())
+	return nil
+}
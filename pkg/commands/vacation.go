@@ -0,0 +1,117 @@
+// Package commands implements CLI command handlers.
+package commands
+
+import (
+	"fmt"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// StartVacation puts all habits on vacation from from ("today", "tomorrow" or
+// YYYY-MM-DD; default today) through until, or until EndVacation is called
+// if until is empty. The vacation is stored once next to the habits rather
+// than in each of them, so it also covers habits added or unarchived while it
+// lasts. Vacation days do not break any streak.
+func StartVacation(store storage.Storage, clock Clock, from, until string) error {
+	today := clock.Today()
+	start, err := models.ParseDate(from, today)
+	if err != nil {
+		return err
+	}
+	if until != "" {
+		if until, err = models.ParseDate(until, today); err != nil {
+			return err
+		}
+		if until < start {
+			return fmt.Errorf("vacation cannot end before it starts")
+		}
+	}
+
+	err = store.UpdateData(func(data *storage.Data) error {
+		return data.Vacations.Start(start, until)
+	})
+	if err != nil {
+		return err
+	}
+
+	if until == "" {
+		fmt.Printf("🏖  Vacation starts on %s for all habits. End it with: habit vacation end\n", start)
+	} else {
+		fmt.Printf("🏖  Vacation from %s through %s for all habits. Your streaks are kept.\n", start, until)
+	}
+	return nil
+}
+
+// EndVacation ends the current vacation so habits are due again from today,
+// or cancels the next planned one.
+func EndVacation(store storage.Storage, clock Clock) error {
+	today := clock.Today()
+	todayStr := today.Format(models.DateFormat)
+	var current bool
+	var next models.Break
+	err := store.UpdateData(func(data *storage.Data) error {
+		_, current = data.Vacations.On(todayStr)
+		next, _ = data.Vacations.Next(todayStr)
+		if !data.Vacations.End(today) {
+			return fmt.Errorf("not on vacation")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if current {
+		fmt.Println("✓ Welcome back! Vacation ended; habits are due again from today.")
+	} else {
+		fmt.Printf("✓ Cancelled the vacation planned from %s.\n", next.Start)
+	}
+	return nil
+}
+
+// VacationStatus shows whether a vacation is in effect today, and the next
+// planned one.
+func VacationStatus(store storage.Storage, clock Clock) error {
+	data, err := store.LoadData()
+	if err != nil {
+		return fmt.Errorf("failed to load vacations: %w", err)
+	}
+
+	todayStr := clock.Today().Format(models.DateFormat)
+	vacation, ok := data.Vacations.On(todayStr)
+	next, planned := data.Vacations.Next(todayStr)
+
+	switch {
+	case ok:
+		fmt.Println(vacationSummary(vacation))
+	case planned:
+		fmt.Println("Not on vacation.")
+	default:
+		fmt.Println("Not on vacation.")
+		fmt.Println("\nTo start one, use:")
+		fmt.Println("  habit vacation start [--from YYYY-MM-DD] [--until YYYY-MM-DD]")
+		return nil
+	}
+	if planned {
+		fmt.Println(plannedVacationSummary(next))
+	}
+	return nil
+}
+
+// vacationSummary describes a vacation, e.g. "🏖  On vacation since 2025-01-10 until 2025-01-20".
+func vacationSummary(b models.Break) string {
+	if b.End == "" {
+		return fmt.Sprintf("🏖  On vacation since %s", b.Start)
+	}
+	return fmt.Sprintf("🏖  On vacation since %s until %s", b.Start, b.End)
+}
+
+// plannedVacationSummary describes a vacation that has not started yet, e.g.
+// "🗓  Vacation planned from 2025-02-01 until 2025-02-07".
+func plannedVacationSummary(b models.Break) string {
+	if b.End == "" {
+		return fmt.Sprintf("🗓  Vacation planned from %s", b.Start)
+	}
+	return fmt.Sprintf("🗓  Vacation planned from %s until %s", b.Start, b.End)
+}
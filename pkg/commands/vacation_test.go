@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestVacation(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	today := time.Now()
+	day := func(offset int) string {
+		return today.AddDate(0, 0, offset).Format(models.DateFormat)
+	}
+	vacations := func() models.Vacations {
+		data, err := store.LoadData()
+		if err != nil {
+			t.Fatalf("LoadData failed: %v", err)
+		}
+		return data.Vacations
+	}
+	exercise := func() *models.Habit {
+		habits, _ := store.Load()
+		habit, _ := habits.Find("Exercise")
+		return habit
+	}
+
+	// Done until five days ago, then away
+	habits := models.HabitList{
+		{Name: "Exercise", Archived: true, History: []models.Completion{{Date: day(-7)}, {Date: day(-6)}, {Date: day(-5)}}},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := EndVacation(store, Clock{}); err == nil {
+		t.Error("Expected error when not on vacation")
+	}
+	if err := StartVacation(store, Clock{}, day(-4), day(-5)); err == nil {
+		t.Error("Expected error for vacation ending before it starts")
+	}
+	if err := StartVacation(store, Clock{}, day(-4), ""); err != nil {
+		t.Fatalf("StartVacation failed: %v", err)
+	}
+
+	// The vacation is stored once rather than in each habit, and covers
+	// habits unarchived while it lasts
+	if loaded, _ := store.Load(); len(loaded[0].Breaks) != 0 {
+		t.Errorf("Expected no breaks stored in the habit, got %v", loaded[0].Breaks)
+	}
+	if _, err := updateHabit(store, "Exercise", func(habit *models.Habit) error {
+		habit.Archived = false
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to unarchive habit: %v", err)
+	}
+	if got := exercise().CurrentStreak(today); got != 3 {
+		t.Errorf("Expected streak 3 during vacation, got %d", got)
+	}
+	if exercise().IsDue(today) {
+		t.Error("Expected habit not to be due on vacation")
+	}
+
+	if err := EndVacation(store, Clock{}); err != nil {
+		t.Fatalf("EndVacation failed: %v", err)
+	}
+	if !exercise().IsDue(today) {
+		t.Error("Expected habit to be due after vacation")
+	}
+	if got := exercise().CurrentStreak(today); got != 3 {
+		t.Errorf("Expected streak 3 after vacation, got %d", got)
+	}
+
+	// A vacation can be planned ahead and cancelled before it starts
+	if err := StartVacation(store, Clock{}, day(3), day(5)); err != nil {
+		t.Fatalf("StartVacation in the future failed: %v", err)
+	}
+	if _, ok := vacations().Next(day(0)); !ok {
+		t.Error("Expected a planned vacation")
+	}
+	if err := EndVacation(store, Clock{}); err != nil {
+		t.Fatalf("EndVacation of a planned vacation failed: %v", err)
+	}
+	if _, ok := vacations().Next(day(0)); ok {
+		t.Error("Expected the planned vacation to be cancelled")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tmpDir := t.TempDir()
+	storePath := filepath.Join(tmpDir, "habits.json")
+	store := storage.NewJSONStorage(storePath, storage.Options{})
+
+	today := time.Now()
+	twoDaysAgo := today.AddDate(0, 0, -2).Format(models.DateFormat)
+	habits := models.HabitList{
+		{Name: "Reading", History: []models.Completion{{Date: twoDaysAgo}}},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	if err := Freeze(store, Clock{}, "Reading", "yesterday"); err == nil {
+		t.Error("Expected error without freeze tokens")
+	}
+	if err := AddFreezes(store, "Reading", 1); err != nil {
+		t.Fatalf("AddFreezes failed: %v", err)
+	}
+	if err := Freeze(store, Clock{}, "Reading", "yesterday"); err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+
+	loaded, _ := store.Load()
+	reading, _ := loaded.Find("Reading")
+	if reading.Freezes != 0 {
+		t.Errorf("Expected no freezes left, got %d", reading.Freezes)
+	}
+	if got := reading.CurrentStreak(today); got != 1 {
+		t.Errorf("Expected streak 1 kept by freeze, got %d", got)
+	}
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// BreakReason describes why a habit is on a break.
+type BreakReason string
+
+// Supported break reasons.
+const (
+	BreakPause    BreakReason = "pause"    // The habit was paused on its own
+	BreakVacation BreakReason = "vacation" // All habits are on vacation
+	BreakFreeze   BreakReason = "freeze"   // A freeze token covers a missed day
+)
+
+// Break is a period during which a habit is not expected to be done. Days in
+// a break neither extend nor break the streak.
+type Break struct {
+	Start  string      `json:"start"`            // First day of the break in YYYY-MM-DD format
+	End    string      `json:"end,omitempty"`    // Last day of the break, empty while open-ended
+	Reason BreakReason `json:"reason,omitempty"` // Why the habit is on a break
+}
+
+// Covers reports whether the break includes the given date.
+func (b Break) Covers(date string) bool {
+	return date >= b.Start && (b.End == "" || date <= b.End)
+}
+
+// validate checks that the break has valid dates.
+func (b Break) validate() error {
+	if _, err := time.Parse(DateFormat, b.Start); err != nil {
+		return fmt.Errorf("invalid break start: %w", err)
+	}
+	if b.End != "" {
+		if _, err := time.Parse(DateFormat, b.End); err != nil {
+			return fmt.Errorf("invalid break end: %w", err)
+		}
+		if b.End < b.Start {
+			return fmt.Errorf("break ends before it starts")
+		}
+	}
+	return nil
+}
+
+// IsExcused reports whether the habit is on a break or on vacation on the
+// given date.
+func (h *Habit) IsExcused(date string) bool {
+	_, ok := h.BreakOn(date)
+	return ok
+}
+
+// excusedDay is IsExcused for a parsed day, as used by Schedule.streak.
+func (h *Habit) excusedDay(day time.Time) bool {
+	return h.IsExcused(day.Format(DateFormat))
+}
+
+// BreakOn returns the break covering the given date, if any, including a
+// vacation set with SetVacations. Avoid habits do not go on vacation.
+func (h *Habit) BreakOn(date string) (Break, bool) {
+	for _, b := range h.Breaks {
+		if b.Covers(date) {
+			return b, true
+		}
+	}
+	if !h.IsAvoid() {
+		return h.vacations.On(date)
+	}
+	return Break{}, false
+}
+
+// PausedUntil returns the last day of the pause covering date, "" for an
+// open-ended pause, and false if the habit is not paused on that date.
+func (h *Habit) PausedUntil(date string) (string, bool) {
+	return h.breakUntil(BreakPause, date)
+}
+
+// breakUntil returns the last day of the break with the given reason covering
+// date, "" if it is open-ended, and false if there is no such break.
+func (h *Habit) breakUntil(reason BreakReason, date string) (string, bool) {
+	for _, b := range h.Breaks {
+		if b.Reason == reason && b.Covers(date) {
+			return b.End, true
+		}
+	}
+	return "", false
+}
+
+// Pause puts the habit on a break from start through until (inclusive). An
+// empty until pauses the habit until Resume is called.
+func (h *Habit) Pause(start, until string) error {
+	if h.IsAvoid() {
+		return fmt.Errorf("avoid habits cannot be paused")
+	}
+	if _, paused := h.PausedUntil(start); paused {
+		return fmt.Errorf("habit '%s' is already paused on %s", h.Name, start)
+	}
+
+	b := Break{Start: start, End: until, Reason: BreakPause}
+	if err := b.validate(); err != nil {
+		return err
+	}
+	h.Breaks = append(h.Breaks, b)
+	return nil
+}
+
+// Resume ends the pause covering today so the habit is due again from today.
+func (h *Habit) Resume(today time.Time) error {
+	if !h.endBreak(BreakPause, today) {
+		return fmt.Errorf("habit '%s' is not paused", h.Name)
+	}
+	return nil
+}
+
+// endBreak ends the break with the given reason that covers today, so that
+// today is no longer excused. It reports whether such a break was found.
+func (h *Habit) endBreak(reason BreakReason, today time.Time) bool {
+	todayStr := today.Format(DateFormat)
+	for i, b := range h.Breaks {
+		if b.Reason != reason || !b.Covers(todayStr) {
+			continue
+		}
+		if b.Start == todayStr {
+			// Started today - drop the break entirely
+			h.Breaks = append(h.Breaks[:i], h.Breaks[i+1:]...)
+		} else {
+			h.Breaks[i].End = today.AddDate(0, 0, -1).Format(DateFormat)
+		}
+		return true
+	}
+	return false
+}
+
+// UseFreeze spends one of the habit's freeze tokens to excuse a missed day.
+func (h *Habit) UseFreeze(date string) error {
+	if h.IsAvoid() {
+		return fmt.Errorf("avoid habits cannot be frozen")
+	}
+	if h.Freezes < 1 {
+		return fmt.Errorf("habit '%s' has no freeze tokens left", h.Name)
+	}
+	if h.HasEntry(date) {
+		return fmt.Errorf("habit '%s' is already marked for %s", h.Name, date)
+	}
+	if h.IsExcused(date) {
+		return fmt.Errorf("habit '%s' is already excused on %s", h.Name, date)
+	}
+
+	b := Break{Start: date, End: date, Reason: BreakFreeze}
+	if err := b.validate(); err != nil {
+		return err
+	}
+	h.Breaks = append(h.Breaks, b)
+	h.Freezes--
+	return nil
+}
+
+// AddFreezes gives the habit more freeze tokens.
+func (h *Habit) AddFreezes(n int) error {
+	if n < 1 {
+		return fmt.Errorf("number of freezes must be positive")
+	}
+	if h.IsAvoid() {
+		return fmt.Errorf("avoid habits cannot be frozen")
+	}
+	h.Freezes += n
+	return nil
+}
+
+// Vacations are the periods in which all habits are on vacation. They are
+// stored once, apart from the habits, and apply to every habit that can be
+// missed, including habits added or unarchived during a vacation.
+type Vacations []Break
+
+// On returns the vacation in effect on the given date, if any.
+func (v Vacations) On(date string) (Break, bool) {
+	for _, b := range v {
+		if b.Covers(date) {
+			return b, true
+		}
+	}
+	return Break{}, false
+}
+
+// Next returns the first vacation starting after the given date, if any.
+func (v Vacations) Next(date string) (Break, bool) {
+	next, found := Break{}, false
+	for _, b := range v {
+		if b.Start > date && (!found || b.Start < next.Start) {
+			next, found = b, true
+		}
+	}
+	return next, found
+}
+
+// Validate checks that every vacation has valid dates.
+func (v Vacations) Validate() error {
+	for _, b := range v {
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start adds a vacation from start through until (inclusive, or open-ended
+// if empty). The start may lie in the future to plan a vacation ahead.
+func (v *Vacations) Start(start, until string) error {
+	b := Break{Start: start, End: until, Reason: BreakVacation}
+	if err := b.validate(); err != nil {
+		return err
+	}
+	for _, other := range *v {
+		if other.overlaps(b) {
+			if other.Covers(start) {
+				return fmt.Errorf("already on vacation on %s", start)
+			}
+			return fmt.Errorf("vacation overlaps the one from %s", other.Start)
+		}
+	}
+	*v = append(*v, b)
+	return nil
+}
+
+// End ends the vacation in effect today, so habits are due again from today,
+// or else cancels the next planned one. It reports whether there was one.
+func (v *Vacations) End(today time.Time) bool {
+	todayStr := today.Format(DateFormat)
+	i := slices.IndexFunc(*v, func(b Break) bool { return b.Covers(todayStr) })
+	if i >= 0 && (*v)[i].Start < todayStr {
+		(*v)[i].End = today.AddDate(0, 0, -1).Format(DateFormat)
+		return true
+	}
+	if i < 0 {
+		next, ok := v.Next(todayStr)
+		if !ok {
+			return false
+		}
+		i = slices.Index(*v, next)
+	}
+	// Started today or not started yet - drop the vacation entirely
+	*v = slices.Delete(*v, i, i+1)
+	return true
+}
+
+// overlaps reports whether two breaks have a day in common.
+func (b Break) overlaps(other Break) bool {
+	return (b.End == "" || other.Start <= b.End) && (other.End == "" || b.Start <= other.End)
+}
+
+// SetVacations makes the habits follow the given vacations, as storage does
+// when loading them. The vacations are not saved with each habit, and the
+// habits' own breaks are not changed.
+func (hl HabitList) SetVacations(vacations Vacations) {
+	for i := range hl {
+		hl[i].vacations = vacations
+	}
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHabit_PausedStreak(t *testing.T) {
+	threePerWeek, _ := ParseSchedule("3/week")
+
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		dates      []string
+		breaks     []Break
+		today      time.Time
+		wantStreak int
+	}{
+		{
+			name:       "pause bridges gap",
+			dates:      []string{"2025-01-01", "2025-01-02", "2025-01-08"},
+			breaks:     []Break{{Start: "2025-01-03", End: "2025-01-07", Reason: BreakPause}},
+			today:      time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC),
+			wantStreak: 3,
+		},
+		{
+			name:       "ongoing pause keeps streak",
+			dates:      []string{"2025-01-01", "2025-01-02"},
+			breaks:     []Break{{Start: "2025-01-03", Reason: BreakPause}},
+			today:      time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC),
+			wantStreak: 2,
+		},
+		{
+			name:       "missed day after pause breaks streak",
+			dates:      []string{"2025-01-01", "2025-01-02"},
+			breaks:     []Break{{Start: "2025-01-03", End: "2025-01-05", Reason: BreakPause}},
+			today:      time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC),
+			wantStreak: 0,
+		},
+		{
+			// Week of 2025-01-13 is paused and short of the target
+			name:     "paused week is skipped",
+			schedule: threePerWeek,
+			dates: []string{
+				"2025-01-06", "2025-01-07", "2025-01-08",
+				"2025-01-13",
+				"2025-01-20", "2025-01-21", "2025-01-22",
+			},
+			breaks:     []Break{{Start: "2025-01-15", End: "2025-01-19", Reason: BreakPause}},
+			today:      time.Date(2025, 1, 22, 9, 0, 0, 0, time.UTC),
+			wantStreak: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit := Habit{Name: "Test", Schedule: tt.schedule, Breaks: tt.breaks}
+			for _, d := range tt.dates {
+				habit.History = append(habit.History, Completion{Date: d})
+			}
+			if err := habit.Recalculate(); err != nil {
+				t.Fatalf("Recalculate() error = %v", err)
+			}
+			if got := habit.CurrentStreak(tt.today); got != tt.wantStreak {
+				t.Errorf("CurrentStreak() = %v, want %v", got, tt.wantStreak)
+			}
+		})
+	}
+}
+
+func TestHabit_PauseResume(t *testing.T) {
+	habit := Habit{Name: "Run"}
+	today := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	if err := habit.Pause("2025-01-10", "2025-01-20"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if err := habit.Pause("2025-01-15", ""); err == nil {
+		t.Error("Pause() expected error for overlapping pause")
+	}
+	if until, paused := habit.PausedUntil("2025-01-15"); !paused || until != "2025-01-20" {
+		t.Errorf("PausedUntil() = %v, %v, want 2025-01-20, true", until, paused)
+	}
+	if habit.IsDue(today) {
+		t.Error("IsDue() paused habits are not due")
+	}
+
+	if err := habit.Resume(today); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if habit.Breaks[0].End != "2025-01-14" {
+		t.Errorf("Resume() end = %v, want 2025-01-14", habit.Breaks[0].End)
+	}
+	if !habit.IsDue(today) {
+		t.Error("IsDue() resumed habit should be due")
+	}
+	if err := habit.Resume(today); err == nil {
+		t.Error("Resume() expected error when not paused")
+	}
+
+	if err := habit.Pause("2025-01-20", "2025-01-19"); err == nil {
+		t.Error("Pause() expected error when until is before start")
+	}
+	avoid := Habit{Name: "Smoking", Kind: KindAvoid}
+	if err := avoid.Pause("2025-01-15", ""); err == nil {
+		t.Error("Pause() expected error for avoid habit")
+	}
+}
+
+func TestHabit_UseFreeze(t *testing.T) {
+	habit := Habit{Name: "Reading", History: []Completion{
+		{Date: "2025-01-12"}, {Date: "2025-01-13"}, {Date: "2025-01-15"},
+	}}
+	today := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	if err := habit.UseFreeze("2025-01-14"); err == nil {
+		t.Error("UseFreeze() expected error without tokens")
+	}
+	if err := habit.AddFreezes(2); err != nil {
+		t.Fatalf("AddFreezes() error = %v", err)
+	}
+	if got := habit.CurrentStreak(today); got != 1 {
+		t.Errorf("CurrentStreak() before freeze = %v, want 1", got)
+	}
+
+	if err := habit.UseFreeze("2025-01-14"); err != nil {
+		t.Fatalf("UseFreeze() error = %v", err)
+	}
+	if got := habit.CurrentStreak(today); got != 3 {
+		t.Errorf("CurrentStreak() after freeze = %v, want 3", got)
+	}
+	if habit.Freezes != 1 {
+		t.Errorf("Freezes = %v, want 1", habit.Freezes)
+	}
+
+	if err := habit.UseFreeze("2025-01-14"); err == nil {
+		t.Error("UseFreeze() expected error for already frozen day")
+	}
+	if err := habit.UseFreeze("2025-01-13"); err == nil {
+		t.Error("UseFreeze() expected error for completed day")
+	}
+	if err := habit.AddFreezes(0); err == nil {
+		t.Error("AddFreezes() expected error for zero")
+	}
+}
+
+func TestVacations(t *testing.T) {
+	habits := HabitList{
+		{Name: "Running", History: []Completion{{Date: "2025-01-01"}, {Date: "2025-01-02"}}},
+		{Name: "Smoking", Kind: KindAvoid, StartDate: "2025-01-01"},
+		{Name: "Old", Archived: true},
+	}
+	today := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	var vacations Vacations
+	if err := vacations.Start("2025-01-03", ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := vacations.Start("2025-01-05", ""); err == nil {
+		t.Error("Start() expected error while on vacation")
+	}
+	if err := vacations.Start("2024-12-30", "2025-01-04"); err == nil {
+		t.Error("Start() expected error for an overlapping vacation")
+	}
+
+	habits.SetVacations(vacations)
+	if got := habits[0].CurrentStreak(today); got != 2 {
+		t.Errorf("CurrentStreak() on vacation = %v, want 2", got)
+	}
+	if habits[1].IsExcused("2025-01-10") {
+		t.Error("IsExcused() avoid habits should not go on vacation")
+	}
+	if len(habits[0].Breaks) != 0 {
+		t.Errorf("SetVacations() changed the habit's breaks: %v", habits[0].Breaks)
+	}
+
+	// Habits added or unarchived during the vacation follow it too
+	habits[2].Archived = false
+	if !habits[2].IsExcused("2025-01-10") {
+		t.Error("IsExcused() unarchived habit should follow the vacation")
+	}
+	if err := habits.Add(Habit{Name: "Swimming"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	habits.SetVacations(vacations)
+	if !habits[3].IsExcused("2025-01-10") {
+		t.Error("IsExcused() new habit should follow the vacation")
+	}
+
+	if !vacations.End(today) {
+		t.Fatal("End() expected a vacation to end")
+	}
+	if _, ok := vacations.On("2025-01-10"); ok {
+		t.Error("On() still in effect after ending")
+	}
+	if _, ok := vacations.On("2025-01-09"); !ok {
+		t.Error("End() should keep past vacation days")
+	}
+	if vacations.End(today) {
+		t.Error("End() expected no vacation to end")
+	}
+}
+
+func TestVacations_Planned(t *testing.T) {
+	today := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	var vacations Vacations
+	if err := vacations.Start("2025-02-01", "2025-02-07"); err != nil {
+		t.Fatalf("Start() in the future error = %v", err)
+	}
+	if err := vacations.Start("2025-01-20", ""); err == nil {
+		t.Error("Start() expected error for an open-ended vacation overlapping a planned one")
+	}
+	if err := vacations.Start("2025-01-20", "2025-01-22"); err != nil {
+		t.Fatalf("Start() before a planned vacation error = %v", err)
+	}
+	if _, ok := vacations.On("2025-01-10"); ok {
+		t.Error("On() planned vacation should not be in effect yet")
+	}
+	if next, ok := vacations.Next("2025-01-10"); !ok || next.Start != "2025-01-20" {
+		t.Errorf("Next() = %v, %v, want the vacation from 2025-01-20", next, ok)
+	}
+
+	// Ending before a vacation starts cancels the next one
+	if !vacations.End(today) {
+		t.Fatal("End() expected to cancel the planned vacation")
+	}
+	if len(vacations) != 1 || vacations[0].Start != "2025-02-01" {
+		t.Errorf("End() left %v, want only the vacation from 2025-02-01", vacations)
+	}
+}
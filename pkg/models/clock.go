@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Clock tells the current time. Code that needs "now" takes a Clock (or a
+// time derived from one) instead of calling time.Now, so that it can be run
+// at any point in time in tests and when replaying historical data.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock that returns the system time.
+type SystemClock struct{}
+
+// Now returns the current system time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock is a Clock that always returns the same time. Use a pointer to
+// move it forward between calls, e.g. in scenario tests.
+type FixedClock struct {
+	Time time.Time
+}
+
+// Now returns the fixed time.
+func (c *FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// Advance moves the clock forward by the given number of days.
+func (c *FixedClock) Advance(days int) {
+	c.Time = c.Time.AddDate(0, 0, days)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calendar decides which calendar day a moment in time belongs to. Days are
+// counted in Location and start at DayStartHour, so with a day start of 4 a
+// habit marked at 1am still counts for the previous day.
+type Calendar struct {
+	Location     *time.Location // Time zone days are counted in (local time if nil)
+	DayStartHour int            // Hour at which a new day starts, 0-23
+}
+
+// Day returns the calendar day that t falls on, as midnight UTC. Representing
+// days in UTC keeps date arithmetic such as AddDate free of DST shifts.
+func (c Calendar) Day(t time.Time) time.Time {
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	// Build the wall-clock time DayStartHour hours earlier; time.Date
+	// normalizes it onto the previous day when needed
+	shifted := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()-c.DayStartHour, t.Minute(), t.Second(), 0, time.UTC)
+	return time.Date(shifted.Year(), shifted.Month(), shifted.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Validate checks that the day start hour is within a day.
+func (c Calendar) Validate() error {
+	if c.DayStartHour < 0 || c.DayStartHour > 23 {
+		return fmt.Errorf("day start hour must be between 0 and 23, got %d", c.DayStartHour)
+	}
+	return nil
+}
+
+// Today returns the calendar day the clock's current time falls on.
+func (c Calendar) Today(clock Clock) time.Time {
+	return c.Day(clock.Now())
+}
+
+// ResolveDate turns user input into a date in YYYY-MM-DD format. It accepts
+// "today", "yesterday" or an explicit date, and rejects dates after today.
+func ResolveDate(input string, today time.Time) (string, error) {
+	dateStr, err := ParseDate(input, today)
+	if err != nil {
+		return "", err
+	}
+	if dateStr > today.Format(DateFormat) {
+		return "", fmt.Errorf("date %s is in the future", dateStr)
+	}
+	return dateStr, nil
+}
+
+// ParseDate turns user input into a date in YYYY-MM-DD format. It accepts
+// "today", "yesterday", "tomorrow" or an explicit date; empty input means today.
+func ParseDate(input string, today time.Time) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "", "today":
+		return today.Format(DateFormat), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1).Format(DateFormat), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).Format(DateFormat), nil
+	}
+
+	date, err := time.Parse(DateFormat, strings.TrimSpace(input))
+	if err != nil {
+		return "", fmt.Errorf("invalid date '%s' (expected YYYY-MM-DD)", input)
+	}
+	return date.Format(DateFormat), nil
+}
+
+// daysBetween returns the number of calendar days from one date to another.
+func daysBetween(from, to string) (int, error) {
+	start, err := time.Parse(DateFormat, from)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date: %w", err)
+	}
+	end, err := time.Parse(DateFormat, to)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date: %w", err)
+	}
+	return dayNumber(end) - dayNumber(start), nil
+}
+
+// dayNumber returns the number of days from 1970-01-01 to the calendar date
+// of t, ignoring the time of day and time zone offset.
+func dayNumber(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Time zone data for the DST tests
+)
+
+func TestResolveDate(t *testing.T) {
+	today := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "empty means today",
+			input: "",
+			want:  "2025-01-15",
+		},
+		{
+			name:  "today",
+			input: "Today",
+			want:  "2025-01-15",
+		},
+		{
+			name:  "yesterday",
+			input: "yesterday",
+			want:  "2025-01-14",
+		},
+		{
+			name:  "explicit past date",
+			input: "2025-01-01",
+			want:  "2025-01-01",
+		},
+		{
+			name:    "future date",
+			input:   "2025-01-16",
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			input:   "01/14/2025",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDate(tt.input, today)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ResolveDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	today := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"empty means today", "", "2025-01-15", false},
+		{"tomorrow", "tomorrow", "2025-01-16", false},
+		{"future date", "2025-02-01", "2025-02-01", false},
+		{"invalid format", "next week", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.input, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendar_Day(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name     string
+		calendar Calendar
+		at       time.Time
+		want     string
+	}{
+		{
+			name:     "midnight boundary",
+			calendar: Calendar{Location: time.UTC},
+			at:       time.Date(2025, 1, 15, 0, 30, 0, 0, time.UTC),
+			want:     "2025-01-15",
+		},
+		{
+			name:     "before day start counts for previous day",
+			calendar: Calendar{Location: time.UTC, DayStartHour: 4},
+			at:       time.Date(2025, 1, 15, 1, 30, 0, 0, time.UTC),
+			want:     "2025-01-14",
+		},
+		{
+			name:     "at day start",
+			calendar: Calendar{Location: time.UTC, DayStartHour: 4},
+			at:       time.Date(2025, 1, 15, 4, 0, 0, 0, time.UTC),
+			want:     "2025-01-15",
+		},
+		{
+			name:     "day start across month boundary",
+			calendar: Calendar{Location: time.UTC, DayStartHour: 4},
+			at:       time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC),
+			want:     "2025-02-28",
+		},
+		{
+			name:     "time zone ahead of UTC",
+			calendar: Calendar{Location: tokyo},
+			at:       time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC),
+			want:     "2025-01-16",
+		},
+		{
+			// Clocks jump from 02:00 to 03:00 on 2025-03-30 in Berlin
+			name:     "spring forward night",
+			calendar: Calendar{Location: berlin, DayStartHour: 4},
+			at:       time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), // 03:30 CEST
+			want:     "2025-03-29",
+		},
+		{
+			// Clocks fall back from 03:00 to 02:00 on 2025-10-26 in Berlin
+			name:     "fall back night",
+			calendar: Calendar{Location: berlin},
+			at:       time.Date(2025, 10, 25, 22, 30, 0, 0, time.UTC), // 00:30 CEST
+			want:     "2025-10-26",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := tt.calendar.Day(tt.at)
+			if got := day.Format(DateFormat); got != tt.want {
+				t.Errorf("Day() = %v, want %v", got, tt.want)
+			}
+			if day.Location() != time.UTC || day.Hour() != 0 {
+				t.Errorf("Day() = %v, want midnight UTC", day)
+			}
+		})
+	}
+
+	if err := (Calendar{DayStartHour: 24}).Validate(); err == nil {
+		t.Error("Validate() expected error for day start hour 24")
+	}
+}
+
+func TestDaysBetween_DST(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     int
+	}{
+		{"2025-03-29", "2025-03-31", 2}, // Spring forward in Europe
+		{"2025-10-25", "2025-10-27", 2}, // Fall back in Europe
+		{"2024-12-31", "2025-03-01", 60},
+		{"2025-01-15", "2025-01-14", -1},
+	}
+
+	for _, tt := range tests {
+		got, err := daysBetween(tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("daysBetween(%s, %s) error = %v", tt.from, tt.to, err)
+		}
+		if got != tt.want {
+			t.Errorf("daysBetween(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -3,24 +3,71 @@ package models
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// DateFormat is the layout used for all stored dates.
+const DateFormat = "2006-01-02"
+
+// HabitKind distinguishes habits being built from habits being quit.
+type HabitKind string
+
+// Supported habit kinds.
+const (
+	KindBuild HabitKind = "build" // Completions are wanted (default)
+	KindAvoid HabitKind = "avoid" // Completions are relapses; the streak is time since the last one
+)
+
+// Completion records a single day on which a habit was completed.
+type Completion struct {
+	Date      string    `json:"date"`               // Completion date in YYYY-MM-DD format
+	Timestamp time.Time `json:"timestamp,omitzero"` // When the completion was recorded
+	Amount    float64   `json:"amount,omitempty"`   // Amount logged that day, for habits with a target
+	Note      string    `json:"note,omitempty"`     // Free-text note attached to the completion
+}
+
 // Habit represents a single habit being tracked with its streak information.
+// LastDone and Streak are derived from History and kept for readability of
+// the data file and compatibility with older versions.
 type Habit struct {
-	Name     string `json:"name"`     // Name of the habit
-	LastDone string `json:"last_done"` // Last completion date in YYYY-MM-DD format
-	Streak   int    `json:"streak"`   // Current streak count (consecutive days)
+	ID       string       `json:"id,omitempty"`      // Stable identifier that survives renames
+	Name     string       `json:"name"`              // Name of the habit
+	LastDone string       `json:"last_done"`         // Last completion date in YYYY-MM-DD format
+	Streak   int          `json:"streak"`            // Current streak count (days, weeks or months depending on schedule)
+	History  []Completion `json:"history,omitempty"` // All completions, ordered by date
+	Schedule Schedule     `json:"schedule,omitzero"` // When the habit is due (daily if empty)
+	Target   float64      `json:"target,omitempty"`  // Daily amount needed for a day to count (0 for yes/no habits)
+	Unit     string       `json:"unit,omitempty"`    // Unit of the target, e.g. "glasses" or "minutes"
+
+	Kind        HabitKind `json:"kind,omitempty"`        // Build (default) or avoid
+	StartDate   string    `json:"start_date,omitempty"`  // Date tracking started in YYYY-MM-DD format
+	Tags        []string  `json:"tags,omitempty"`        // Lowercase tags used to group habits
+	Description string    `json:"description,omitempty"` // Free-text description of the habit
+	Color       string    `json:"color,omitempty"`       // Display color, one of Colors
+	Archived    bool      `json:"archived,omitempty"`    // Hidden from list and stats, history kept
+	Breaks      []Break   `json:"breaks,omitempty"`      // Periods in which the habit is not expected to be done
+	Freezes     int       `json:"freezes,omitempty"`     // Unused freeze tokens that can excuse a missed day
+	ResetAt     time.Time `json:"reset_at,omitzero"`     // When the streak was last reset; earlier completions do not count toward it
+	ResetDate   string    `json:"reset_date,omitempty"`  // Day of the last reset in YYYY-MM-DD format, as the user's calendar saw it
+
+	vacations Vacations // Vacations of all habits, see HabitList.SetVacations
 }
 
+// Colors lists the color names a habit can be displayed in.
+var Colors = []string{"red", "green", "yellow", "blue", "purple", "cyan", "gray", "white"}
+
 // Validate checks if the habit has valid data.
 func (h *Habit) Validate() error {
 	if strings.TrimSpace(h.Name) == "" {
 		return fmt.Errorf("habit name cannot be empty")
 	}
 	if h.LastDone != "" {
-		_, err := time.Parse("2006-01-02", h.LastDone)
+		_, err := time.Parse(DateFormat, h.LastDone)
 		if err != nil {
 			return fmt.Errorf("invalid date format for LastDone: %w", err)
 		}
@@ -28,81 +75,608 @@ func (h *Habit) Validate() error {
 	if h.Streak < 0 {
 		return fmt.Errorf("streak cannot be negative")
 	}
+	for _, c := range h.History {
+		if _, err := time.Parse(DateFormat, c.Date); err != nil {
+			return fmt.Errorf("invalid date format in history: %w", err)
+		}
+	}
+	if err := h.Schedule.Validate(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if h.Target < 0 {
+		return fmt.Errorf("target cannot be negative")
+	}
+	for _, c := range h.History {
+		if c.Amount < 0 {
+			return fmt.Errorf("amount on %s cannot be negative", c.Date)
+		}
+	}
+	if h.Kind != "" && h.Kind != KindBuild && h.Kind != KindAvoid {
+		return fmt.Errorf("unknown habit kind '%s'", h.Kind)
+	}
+	if h.StartDate != "" {
+		if _, err := time.Parse(DateFormat, h.StartDate); err != nil {
+			return fmt.Errorf("invalid date format for StartDate: %w", err)
+		}
+	}
+	if h.IsAvoid() && (h.IsQuantitative() || !h.Schedule.IsDaily()) {
+		return fmt.Errorf("avoid habits cannot have a target or schedule")
+	}
+	for _, tag := range h.Tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+	if h.Color != "" && !slices.Contains(Colors, h.Color) {
+		return fmt.Errorf("unknown color '%s' (use one of: %s)", h.Color, strings.Join(Colors, ", "))
+	}
+	for _, b := range h.Breaks {
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+	if h.Freezes < 0 {
+		return fmt.Errorf("freezes cannot be negative")
+	}
 	return nil
 }
 
-// UpdateStreak updates the habit's streak based on the last completion date.
-// It increments the streak if completed consecutively, otherwise resets to 1.
-func (h *Habit) UpdateStreak(today time.Time) error {
-	todayStr := today.Format("2006-01-02")
+// NormalizeTag trims and lowercases a tag so that tags match case-insensitively.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
 
-	// Check if already marked today
-	if h.LastDone == todayStr {
-		return fmt.Errorf("habit already marked for today")
+// validateTag checks that a tag is non-empty and contains no separators.
+func validateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
 	}
+	if strings.ContainsAny(tag, " ,;\t") {
+		return fmt.Errorf("tag '%s' cannot contain spaces, commas or semicolons", tag)
+	}
+	return nil
+}
 
-	// If this is a new habit or first time marking
-	if h.LastDone == "" {
-		h.Streak = 1
-		h.LastDone = todayStr
+// HasTag reports whether the habit has the given tag.
+func (h *Habit) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range h.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag adds a tag to the habit. It returns an error if the tag is invalid
+// or already present.
+func (h *Habit) AddTag(tag string) error {
+	tag = NormalizeTag(tag)
+	if err := validateTag(tag); err != nil {
+		return err
+	}
+	if h.HasTag(tag) {
+		return fmt.Errorf("habit already has tag '%s'", tag)
+	}
+	h.Tags = append(h.Tags, tag)
+	sort.Strings(h.Tags)
+	return nil
+}
+
+// RemoveTag removes a tag from the habit.
+func (h *Habit) RemoveTag(tag string) error {
+	tag = NormalizeTag(tag)
+	for i, t := range h.Tags {
+		if t == tag {
+			h.Tags = append(h.Tags[:i], h.Tags[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("habit does not have tag '%s'", tag)
+}
+
+// IsAvoid reports whether the habit tracks something being quit, where each
+// completion is a relapse.
+func (h *Habit) IsAvoid() bool {
+	return h.Kind == KindAvoid
+}
+
+// CleanDays returns the number of days since the last relapse of an avoid
+// habit, or since tracking started if there has been none.
+func (h *Habit) CleanDays(today time.Time) int {
+	since := h.StartDate
+	if h.LastDone > since {
+		since = h.LastDone
+	}
+	if since == "" {
+		return 0
+	}
+
+	days, err := daysBetween(since, today.Format(DateFormat))
+	if err != nil || days < 0 {
+		return 0
+	}
+	return days
+}
+
+// MigrateHistory builds a completion history for habits stored by older
+// versions, which only kept LastDone and Streak. The streak is expanded into
+// one completion per day ending at LastDone, so the derived values match the
+// stored ones. A zero streak keeps only the completion on LastDone, recorded
+// as reset so the streak stays zero. Habits that already have a history are
+// left untouched.
+func (h *Habit) MigrateHistory() error {
+	if len(h.History) > 0 || h.LastDone == "" {
 		return nil
 	}
 
-	// Parse the last done date
-	lastDone, err := time.Parse("2006-01-02", h.LastDone)
+	lastDone, err := time.Parse(DateFormat, h.LastDone)
 	if err != nil {
 		return fmt.Errorf("invalid last done date: %w", err)
 	}
 
-	// Calculate day difference
-	diff := int(today.Sub(lastDone).Hours() / 24)
+	days := h.Streak
+	if days < 1 {
+		days = 1
+		if !h.IsAvoid() && h.ResetDate == "" {
+			h.ResetDate = h.LastDone
+		}
+	}
+	for i := days - 1; i >= 0; i-- {
+		h.History = append(h.History, Completion{
+			Date: lastDone.AddDate(0, 0, -i).Format(DateFormat),
+		})
+	}
+
+	return h.Recalculate()
+}
 
-	// Update streak based on difference
-	if diff == 1 {
-		// Consecutive day - increment streak
-		h.Streak++
-	} else {
-		// Gap in days - reset streak
-		h.Streak = 1
+// Recalculate sorts the completion history and derives LastDone and Streak
+// from it. The streak is counted according to the habit's schedule, as of the
+// most recent completion. For habits with a target, only days on which the
+// target was reached count as completions.
+func (h *Habit) Recalculate() error {
+	sort.SliceStable(h.History, func(i, j int) bool {
+		return h.History[i].Date < h.History[j].Date
+	})
+
+	h.LastDone = ""
+	h.Streak = 0
+	for i := len(h.History) - 1; i >= 0; i-- {
+		if h.counts(h.History[i]) {
+			h.LastDone = h.History[i].Date
+			break
+		}
+	}
+	if h.LastDone == "" || h.IsAvoid() {
+		// Avoid habits have no stored streak; it grows daily (see CleanDays)
+		return nil
 	}
 
-	h.LastDone = todayStr
+	streak, err := h.streakAsOf(h.LastDone)
+	if err != nil {
+		return err
+	}
+	h.Streak = streak
 	return nil
 }
 
+// CurrentStreak returns the streak as of today. Unlike Streak, which is
+// counted as of the last completion, it drops to zero once a due day or
+// period has been missed. Today itself never breaks the streak. For avoid
+// habits it is the number of clean days.
+func (h *Habit) CurrentStreak(today time.Time) int {
+	if h.IsAvoid() {
+		return h.CleanDays(today)
+	}
+	if len(h.History) == 0 {
+		return h.Streak
+	}
+	streak, err := h.streakAsOf(today.Format(DateFormat))
+	if err != nil {
+		return h.Streak
+	}
+	return streak
+}
+
+// ResetStreak resets the streak to zero at the given time, on the calendar
+// day today. The history is kept; completions recorded before the reset no
+// longer count toward the streak. Avoid habits start counting clean days
+// again from today.
+func (h *Habit) ResetStreak(at, today time.Time) error {
+	if err := h.MigrateHistory(); err != nil {
+		return err
+	}
+	if h.IsAvoid() {
+		h.StartDate = today.Format(DateFormat)
+		return nil
+	}
+	h.ResetAt = at
+	h.ResetDate = today.Format(DateFormat)
+	return h.Recalculate()
+}
+
+// afterReset reports whether a completion was recorded after the last reset.
+// Completions without a timestamp, or after a reset whose time is unknown,
+// count if they fall on a later day than the reset.
+func (h *Habit) afterReset(c Completion) bool {
+	switch {
+	case h.ResetDate == "":
+		return true
+	case c.Timestamp.IsZero() || h.ResetAt.IsZero():
+		return c.Date > h.ResetDate
+	default:
+		return c.Timestamp.After(h.ResetAt)
+	}
+}
+
+// streakAsOf computes the streak on the given date from the completion history.
+func (h *Habit) streakAsOf(date string) (int, error) {
+	asOf, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date: %w", err)
+	}
+
+	done := h.doneDates()
+	for _, c := range h.History {
+		if !h.afterReset(c) {
+			delete(done, c.Date)
+		}
+	}
+	var first time.Time
+	for date := range done {
+		day, err := time.Parse(DateFormat, date)
+		if err != nil {
+			return 0, fmt.Errorf("invalid date in history: %w", err)
+		}
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+	}
+
+	return h.Schedule.streak(done, h.excusedDay, first, asOf), nil
+}
+
+// doneDates returns the set of dates that count as completed.
+func (h *Habit) doneDates() map[string]bool {
+	done := make(map[string]bool, len(h.History))
+	for _, c := range h.History {
+		if h.counts(c) {
+			done[c.Date] = true
+		}
+	}
+	return done
+}
+
+// counts reports whether a history entry counts as a completed day, which
+// for habits with a target means the target was reached.
+func (h *Habit) counts(c Completion) bool {
+	return !h.IsQuantitative() || c.Amount >= h.Target
+}
+
+// IsDue reports whether the habit still needs to be done on the given day
+// according to its schedule. Habits are not due before their start date,
+// while archived or during a break.
+func (h *Habit) IsDue(today time.Time) bool {
+	if h.IsAvoid() {
+		return false
+	}
+
+	todayStr := today.Format(DateFormat)
+	if h.StartDate > todayStr || h.Archived || h.IsExcused(todayStr) {
+		return false
+	}
+	if h.CompletedOn(todayStr) || h.LastDone == todayStr {
+		return false
+	}
+
+	switch h.Schedule.Kind {
+	case ScheduleWeekdays:
+		return h.Schedule.isScheduled(today)
+	case ScheduleInterval:
+		if h.LastDone == "" {
+			return true
+		}
+		days, err := daysBetween(h.LastDone, todayStr)
+		return err != nil || days >= h.Schedule.Every
+	case ScheduleWeekly, ScheduleMonthly:
+		done, target := h.Progress(today)
+		return done < target
+	default:
+		return true
+	}
+}
+
+// Progress returns the number of completions in the current week or month
+// and the target for that period. For other schedules both values are zero.
+func (h *Habit) Progress(today time.Time) (int, int) {
+	if !h.Schedule.isPeriodic() {
+		return 0, 0
+	}
+
+	day, _ := time.Parse(DateFormat, today.Format(DateFormat))
+	return h.Schedule.countIn(h.doneDates(), h.Schedule.periodStart(day)), h.Schedule.Times
+}
+
+// IsQuantitative reports whether the habit tracks an amount against a daily target.
+func (h *Habit) IsQuantitative() bool {
+	return h.Target > 0
+}
+
+// AmountOn returns the amount logged on the given date.
+func (h *Habit) AmountOn(date string) float64 {
+	for _, c := range h.History {
+		if c.Date == date {
+			return c.Amount
+		}
+	}
+	return 0
+}
+
+// AddAmount adds an amount to the given date's entry, creating it if needed,
+// and recalculates the streak. The day counts toward the streak once the
+// accumulated amount reaches the target.
+func (h *Habit) AddAmount(date string, amount float64, at time.Time) error {
+	if !h.IsQuantitative() {
+		return fmt.Errorf("habit has no target to log amounts against")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if _, err := time.Parse(DateFormat, date); err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+
+	for i := range h.History {
+		if h.History[i].Date == date {
+			h.History[i].Amount += amount
+			h.History[i].Timestamp = at
+			return h.Recalculate()
+		}
+	}
+
+	h.History = append(h.History, Completion{Date: date, Timestamp: at, Amount: amount})
+	return h.Recalculate()
+}
+
+// SetTarget turns the habit into a measurable one with a daily target, or
+// back into a yes/no habit when target is zero. Days completed before a
+// target was set are treated as having reached it.
+func (h *Habit) SetTarget(target float64, unit string) error {
+	if math.IsNaN(target) || math.IsInf(target, 0) {
+		return fmt.Errorf("target must be a finite number")
+	}
+	if target < 0 {
+		return fmt.Errorf("target cannot be negative")
+	}
+	if h.IsAvoid() && target > 0 {
+		return fmt.Errorf("avoid habits cannot have a target")
+	}
+	if err := h.MigrateHistory(); err != nil {
+		return err
+	}
+
+	if !h.IsQuantitative() && target > 0 {
+		for i := range h.History {
+			if h.History[i].Amount == 0 {
+				h.History[i].Amount = target
+			}
+		}
+	}
+
+	h.Target = target
+	h.Unit = strings.TrimSpace(unit)
+	if target == 0 {
+		h.Unit = ""
+	}
+	return h.Recalculate()
+}
+
+// TargetString describes the daily target, e.g. "8 glasses/day".
+func (h *Habit) TargetString() string {
+	if h.Unit == "" {
+		return FormatAmount(h.Target) + "/day"
+	}
+	return FormatAmount(h.Target) + " " + h.Unit + "/day"
+}
+
+// AddCompletion records a completion on the given date and recalculates the
+// streak. The timestamp records when the completion was logged.
+func (h *Habit) AddCompletion(date string, at time.Time) error {
+	if _, err := time.Parse(DateFormat, date); err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	if err := h.MigrateHistory(); err != nil {
+		return err
+	}
+	if h.HasEntry(date) {
+		return fmt.Errorf("habit already marked for %s", date)
+	}
+
+	completion := Completion{Date: date, Timestamp: at}
+	if h.IsQuantitative() {
+		completion.Amount = h.Target
+	}
+	h.History = append(h.History, completion)
+	return h.Recalculate()
+}
+
+// RemoveCompletion deletes the completion on the given date and recalculates
+// the streak from the remaining history.
+func (h *Habit) RemoveCompletion(date string) error {
+	if err := h.MigrateHistory(); err != nil {
+		return err
+	}
+
+	for i, c := range h.History {
+		if c.Date == date {
+			h.History = append(h.History[:i], h.History[i+1:]...)
+			return h.Recalculate()
+		}
+	}
+	return fmt.Errorf("habit not marked for %s", date)
+}
+
+// AddNote attaches a note to the entry on the given date. Notes added to a
+// day that already has one are appended.
+func (h *Habit) AddNote(date, note string) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil
+	}
+
+	for i := range h.History {
+		if h.History[i].Date == date {
+			if h.History[i].Note == "" {
+				h.History[i].Note = note
+			} else {
+				h.History[i].Note += "; " + note
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("habit not marked for %s", date)
+}
+
+// NotesMatching returns the history entries whose notes contain the query
+// (case-insensitive), newest first.
+func (h *Habit) NotesMatching(query string) []Completion {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var matches []Completion
+	for i := len(h.History) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(h.History[i].Note), query) {
+			matches = append(matches, h.History[i])
+		}
+	}
+	return matches
+}
+
+// CompletedOn reports whether the habit was completed on the given date. For
+// habits with a target, the day's amount must have reached the target.
+func (h *Habit) CompletedOn(date string) bool {
+	for _, c := range h.History {
+		if c.Date == date {
+			return h.counts(c)
+		}
+	}
+	return false
+}
+
+// HasEntry reports whether anything was logged for the habit on the given date.
+func (h *Habit) HasEntry(date string) bool {
+	for _, c := range h.History {
+		if c.Date == date {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStreak records a completion for today and updates the streak.
+// It increments the streak if completed consecutively, otherwise resets to 1.
+func (h *Habit) UpdateStreak(today time.Time) error {
+	todayStr := today.Format(DateFormat)
+
+	// Check if already marked today
+	if h.LastDone == todayStr {
+		return fmt.Errorf("habit already marked for today")
+	}
+
+	return h.AddCompletion(todayStr, today)
+}
+
 // IsMarkedToday checks if the habit was marked on the given date.
 func (h *Habit) IsMarkedToday(today time.Time) bool {
-	return h.LastDone == today.Format("2006-01-02")
+	return h.LastDone == today.Format(DateFormat)
 }
 
-// DaysSinceLastDone returns the number of days since the habit was last completed.
-func (h *Habit) DaysSinceLastDone() (int, error) {
+// DaysSinceLastDone returns the number of days from the last completion to today.
+func (h *Habit) DaysSinceLastDone(today time.Time) (int, error) {
 	if h.LastDone == "" {
 		return -1, fmt.Errorf("habit has never been completed")
 	}
 
-	lastDone, err := time.Parse("2006-01-02", h.LastDone)
+	lastDone, err := time.Parse(DateFormat, h.LastDone)
 	if err != nil {
 		return -1, fmt.Errorf("invalid last done date: %w", err)
 	}
 
-	today := time.Now()
-	diff := int(today.Sub(lastDone).Hours() / 24)
-	return diff, nil
+	return dayNumber(today) - dayNumber(lastDone), nil
 }
 
-// String returns a formatted string representation of the habit.
-func (h *Habit) String() string {
+// Summary describes the habit on one line, with its streak as of today.
+func (h *Habit) Summary(today time.Time) string {
 	lastDone := h.LastDone
 	if lastDone == "" {
 		lastDone = "Never"
 	}
-	return fmt.Sprintf("- %s | Streak: %d | Last done: %s", h.Name, h.Streak, lastDone)
+	name := h.Name
+	if len(h.Tags) > 0 {
+		name += " [" + strings.Join(h.Tags, ", ") + "]"
+	}
+	if h.IsAvoid() {
+		return fmt.Sprintf("- %s (avoid) | Last relapse: %s", name, lastDone)
+	}
+
+	var details []string
+	if !h.Schedule.IsDaily() {
+		details = append(details, h.Schedule.String())
+	}
+	if h.IsQuantitative() {
+		details = append(details, h.TargetString())
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("- %s | Streak: %d | Last done: %s", name, h.CurrentStreak(today), lastDone)
+	}
+	return fmt.Sprintf("- %s (%s) | Streak: %d %s(s) | Last done: %s",
+		name, strings.Join(details, ", "), h.CurrentStreak(today), h.Schedule.Unit(), lastDone)
+}
+
+// ParseAmount parses a positive amount such as "3" or "2.5".
+func ParseAmount(s string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount '%s'", s)
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+	return amount, nil
+}
+
+// ParseTarget parses a daily target such as "8" or "2.5". Unlike ParseAmount
+// it accepts 0, which removes the target.
+func ParseTarget(s string) (float64, error) {
+	target, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(target) || math.IsInf(target, 0) {
+		return 0, fmt.Errorf("invalid target '%s'", s)
+	}
+	return target, nil
+}
+
+// FormatAmount formats an amount without trailing zeros, e.g. 2.5 or 8.
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
 }
 
 // HabitList represents a collection of habits.
 type HabitList []Habit
 
+// MigrateHistory builds completion histories for all habits stored by older
+// versions. See Habit.MigrateHistory.
+func (hl HabitList) MigrateHistory() error {
+	for i := range hl {
+		if err := hl[i].MigrateHistory(); err != nil {
+			return fmt.Errorf("habit '%s': %w", hl[i].Name, err)
+		}
+	}
+	return nil
+}
+
 // Find returns a habit by name (case-insensitive).
 func (hl HabitList) Find(name string) (*Habit, int) {
 	for i, h := range hl {
@@ -128,46 +702,138 @@ func (hl *HabitList) Remove(index int) error {
 	return nil
 }
 
-// Add adds a new habit to the list.
+// FilterByTag returns the habits that have the given tag. An empty tag
+// returns the whole list.
+func (hl HabitList) FilterByTag(tag string) HabitList {
+	if NormalizeTag(tag) == "" {
+		return hl
+	}
+
+	filtered := HabitList{}
+	for _, h := range hl {
+		if h.HasTag(tag) {
+			filtered = append(filtered, h)
+		}
+	}
+	return filtered
+}
+
+// Active returns the habits that are not archived.
+func (hl HabitList) Active() HabitList {
+	active := HabitList{}
+	for _, h := range hl {
+		if !h.Archived {
+			active = append(active, h)
+		}
+	}
+	return active
+}
+
+// Archived returns the archived habits.
+func (hl HabitList) Archived() HabitList {
+	archived := HabitList{}
+	for _, h := range hl {
+		if h.Archived {
+			archived = append(archived, h)
+		}
+	}
+	return archived
+}
+
+// Tags returns every tag in use with the number of habits carrying it.
+func (hl HabitList) Tags() map[string]int {
+	counts := make(map[string]int)
+	for _, h := range hl {
+		for _, t := range h.Tags {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// Add adds a new habit to the list, giving it a new ID if it has none.
 func (hl *HabitList) Add(habit Habit) error {
 	if err := habit.Validate(); err != nil {
 		return err
 	}
+	for habit.ID == "" || hl.hasID(habit.ID) {
+		habit.ID = NewID()
+	}
 	*hl = append(*hl, habit)
 	return nil
 }
 
-// Stats returns statistics about the habit list.
-func (hl HabitList) Stats() map[string]interface{} {
-	if len(hl) == 0 {
-		return map[string]interface{}{
-			"total":        0,
-			"max_streak":   0,
-			"total_streak": 0,
-			"avg_streak":   0.0,
-		}
+// hasID reports whether a habit with the given ID exists.
+func (hl HabitList) hasID(id string) bool {
+	h, _ := hl.FindByID(id)
+	return h != nil
+}
+
+// StreakUnits lists the units streaks are counted in, in the order Stats
+// reports them.
+var StreakUnits = []string{"day", "completion", "week", "month"}
+
+// StreakKey returns the Stats key of a streak statistic, such as "max" or
+// "avg", for habits whose streaks are counted in unit. Day streaks keep the
+// plain keys, e.g. "max_streak"; others are e.g. "max_week_streak".
+func StreakKey(stat, unit string) string {
+	if unit == "day" {
+		return stat + "_streak"
 	}
+	return stat + "_" + unit + "_streak"
+}
 
-	maxStreak := 0
-	totalStreak := 0
+// Stats calculates statistics for all habits as of today. Streaks are the
+// current ones and are summarized per unit, since day, completion, week and
+// month streaks cannot be added up: "habits_streak" keys count the habits of each unit
+// (StreakKey("habits", unit)), next to their "max", "total" and "avg"
+// streaks. Avoid habits count their clean days.
+func (hl HabitList) Stats(today time.Time) map[string]interface{} {
+	stats := map[string]interface{}{"total": len(hl)}
 	markedToday := 0
-	today := time.Now()
+	dueToday := 0
+	onBreak := 0
 
+	counts := make(map[string]int)
+	maxStreak := make(map[string]int)
+	totalStreak := make(map[string]int)
 	for _, h := range hl {
-		if h.Streak > maxStreak {
-			maxStreak = h.Streak
+		unit := h.Schedule.Unit()
+		streak := h.CurrentStreak(today)
+		counts[unit]++
+		maxStreak[unit] = max(maxStreak[unit], streak)
+		totalStreak[unit] += streak
+		if h.IsAvoid() {
+			continue
 		}
-		totalStreak += h.Streak
 		if h.IsMarkedToday(today) {
 			markedToday++
 		}
+		if h.IsDue(today) {
+			dueToday++
+		}
+		if h.IsExcused(today.Format(DateFormat)) {
+			onBreak++
+		}
 	}
 
-	return map[string]interface{}{
-		"total":        len(hl),
-		"max_streak":   maxStreak,
-		"total_streak": totalStreak,
-		"avg_streak":   float64(totalStreak) / float64(len(hl)),
-		"marked_today": markedToday,
+	for _, unit := range StreakUnits {
+		if counts[unit] == 0 && unit != "day" {
+			continue
+		}
+		avg := 0.0
+		if counts[unit] > 0 {
+			avg = float64(totalStreak[unit]) / float64(counts[unit])
+		}
+		stats[StreakKey("habits", unit)] = counts[unit]
+		stats[StreakKey("max", unit)] = maxStreak[unit]
+		stats[StreakKey("total", unit)] = totalStreak[unit]
+		stats[StreakKey("avg", unit)] = avg
+	}
+	if len(hl) > 0 {
+		stats["marked_today"] = markedToday
+		stats["due_today"] = dueToday
+		stats["on_break"] = onBreak
 	}
+	return stats
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -292,7 +294,7 @@ func TestHabitList_Stats(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.habits.Stats()
+			got := tt.habits.Stats(today)
 			for key, wantVal := range tt.want {
 				gotVal, ok := got[key]
 				if !ok {
@@ -306,3 +308,430 @@ func TestHabitList_Stats(t *testing.T) {
 		})
 	}
 }
+
+func TestHabitList_StatsPerUnit(t *testing.T) {
+	today := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC) // Wednesday
+	habit := func(name, spec string, dates ...string) Habit {
+		schedule, err := ParseSchedule(spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", spec, err)
+		}
+		h := Habit{Name: name, Schedule: schedule}
+		for _, d := range dates {
+			h.History = append(h.History, Completion{Date: d})
+		}
+		h.Recalculate()
+		return h
+	}
+
+	habits := HabitList{
+		// Stored streak 3, but broken since
+		habit("Exercise", "daily", "2025-01-08", "2025-01-09", "2025-01-10"),
+		habit("Reading", "daily", "2025-01-13", "2025-01-14"),
+		habit("Swim", "1/week", "2025-01-01", "2025-01-08", "2025-01-13"),
+		habit("Laundry", "every 3 days", "2025-01-09", "2025-01-12", "2025-01-14"),
+	}
+	got := habits.Stats(today)
+
+	want := map[string]interface{}{
+		"habits_streak":      2,
+		"max_streak":         2,
+		"total_streak":       2,
+		"avg_streak":         1.0,
+		"habits_week_streak": 1,
+		"max_week_streak":    3,
+		"total_week_streak":  3,
+		"avg_week_streak":    3.0,
+
+		"habits_completion_streak": 1,
+		"max_completion_streak":    3,
+		"total_completion_streak":  3,
+		"avg_completion_streak":    3.0,
+	}
+	for key, wantVal := range want {
+		if got[key] != wantVal {
+			t.Errorf("Stats()[%s] = %v, want %v", key, got[key], wantVal)
+		}
+	}
+	if _, ok := got["max_month_streak"]; ok {
+		t.Error("Stats() reports month streaks without monthly habits")
+	}
+}
+
+func TestHabit_Summary(t *testing.T) {
+	h := Habit{Name: "Exercise", History: []Completion{{Date: "2025-01-09"}, {Date: "2025-01-10"}}}
+	h.Recalculate()
+
+	if got := h.Summary(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)); !strings.Contains(got, "Streak: 2") {
+		t.Errorf("Summary() on the last day = %q, want streak 2", got)
+	}
+	if got := h.Summary(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)); !strings.Contains(got, "Streak: 0") {
+		t.Errorf("Summary() after missing days = %q, want streak 0", got)
+	}
+}
+
+func TestHabit_MigrateHistory(t *testing.T) {
+	habit := Habit{Name: "Exercise", LastDone: "2025-01-15", Streak: 3}
+
+	if err := habit.MigrateHistory(); err != nil {
+		t.Fatalf("MigrateHistory() error = %v", err)
+	}
+
+	wantDates := []string{"2025-01-13", "2025-01-14", "2025-01-15"}
+	if len(habit.History) != len(wantDates) {
+		t.Fatalf("MigrateHistory() history length = %v, want %v", len(habit.History), len(wantDates))
+	}
+	for i, want := range wantDates {
+		if habit.History[i].Date != want {
+			t.Errorf("MigrateHistory() history[%d] = %v, want %v", i, habit.History[i].Date, want)
+		}
+	}
+	if habit.Streak != 3 || habit.LastDone != "2025-01-15" {
+		t.Errorf("MigrateHistory() changed derived values: streak = %v, lastDone = %v", habit.Streak, habit.LastDone)
+	}
+}
+
+func TestHabit_MigrateHistoryZeroStreak(t *testing.T) {
+	habit := Habit{Name: "Exercise", LastDone: "2025-01-15", Streak: 0}
+
+	if err := habit.MigrateHistory(); err != nil {
+		t.Fatalf("MigrateHistory() error = %v", err)
+	}
+
+	if len(habit.History) != 1 || habit.History[0].Date != "2025-01-15" {
+		t.Errorf("MigrateHistory() history = %+v, want only 2025-01-15", habit.History)
+	}
+	if habit.Streak != 0 || habit.LastDone != "2025-01-15" {
+		t.Errorf("MigrateHistory() changed derived values: streak = %v, lastDone = %v", habit.Streak, habit.LastDone)
+	}
+	if got := habit.CurrentStreak(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("CurrentStreak() = %d, want 0", got)
+	}
+}
+
+func TestHabit_ResetStreak(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	habit := Habit{Name: "Exercise"}
+	for d := 13; d <= 15; d++ {
+		habit.AddCompletion(day(d).Format(DateFormat), day(d).Add(8*time.Hour))
+	}
+
+	if err := habit.ResetStreak(day(15).Add(12*time.Hour), day(15)); err != nil {
+		t.Fatalf("ResetStreak() error = %v", err)
+	}
+	if len(habit.History) != 3 {
+		t.Errorf("ResetStreak() history = %+v, want it kept", habit.History)
+	}
+	if habit.Streak != 0 || habit.CurrentStreak(day(15)) != 0 {
+		t.Errorf("ResetStreak() streak = %d, current %d, want 0", habit.Streak, habit.CurrentStreak(day(15)))
+	}
+
+	// Completions recorded after the reset count again
+	if err := habit.AddCompletion(day(16).Format(DateFormat), day(16).Add(8*time.Hour)); err != nil {
+		t.Fatalf("AddCompletion() error = %v", err)
+	}
+	if got := habit.CurrentStreak(day(16)); got != 1 {
+		t.Errorf("CurrentStreak() after marking = %d, want 1", got)
+	}
+
+	// A reset after midnight but before the day starts belongs to the day
+	// before, so a completion of the next day without a time still counts
+	late := Habit{Name: "Reading", History: []Completion{{Date: "2025-01-15"}}}
+	if err := late.ResetStreak(day(16).Add(2*time.Hour), day(15)); err != nil {
+		t.Fatalf("ResetStreak() error = %v", err)
+	}
+	if err := late.AddCompletion("2025-01-16", time.Time{}); err != nil {
+		t.Fatalf("AddCompletion() error = %v", err)
+	}
+	if got := late.CurrentStreak(day(16)); got != 1 {
+		t.Errorf("CurrentStreak() after a late reset = %d, want 1", got)
+	}
+
+	// Avoid habits start counting clean days again
+	avoid := Habit{Name: "Smoking", Kind: KindAvoid, StartDate: "2025-01-01"}
+	if err := avoid.ResetStreak(day(15).Add(12*time.Hour), day(15)); err != nil {
+		t.Fatalf("ResetStreak() error = %v", err)
+	}
+	if got := avoid.CurrentStreak(day(17)); got != 2 {
+		t.Errorf("CurrentStreak() of avoid habit = %d, want 2", got)
+	}
+}
+
+func TestHabit_AddCompletion(t *testing.T) {
+	tests := []struct {
+		name         string
+		history      []Completion
+		date         string
+		wantStreak   int
+		wantLastDone string
+		wantErr      bool
+	}{
+		{
+			name:         "first completion",
+			date:         "2025-01-15",
+			wantStreak:   1,
+			wantLastDone: "2025-01-15",
+		},
+		{
+			name:         "extends streak",
+			history:      []Completion{{Date: "2025-01-13"}, {Date: "2025-01-14"}},
+			date:         "2025-01-15",
+			wantStreak:   3,
+			wantLastDone: "2025-01-15",
+		},
+		{
+			name:         "out of order keeps latest date",
+			history:      []Completion{{Date: "2025-01-13"}, {Date: "2025-01-15"}},
+			date:         "2025-01-14",
+			wantStreak:   3,
+			wantLastDone: "2025-01-15",
+		},
+		{
+			name:    "duplicate date",
+			history: []Completion{{Date: "2025-01-15"}},
+			date:    "2025-01-15",
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			date:    "15-01-2025",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit := Habit{Name: "Exercise", History: tt.history}
+			err := habit.AddCompletion(tt.date, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddCompletion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if habit.Streak != tt.wantStreak {
+				t.Errorf("AddCompletion() streak = %v, want %v", habit.Streak, tt.wantStreak)
+			}
+			if habit.LastDone != tt.wantLastDone {
+				t.Errorf("AddCompletion() lastDone = %v, want %v", habit.LastDone, tt.wantLastDone)
+			}
+		})
+	}
+}
+
+func TestHabit_RemoveCompletion(t *testing.T) {
+	habit := Habit{Name: "Exercise", History: []Completion{
+		{Date: "2025-01-13"},
+		{Date: "2025-01-14"},
+		{Date: "2025-01-15"},
+	}}
+
+	if err := habit.RemoveCompletion("2025-01-15"); err != nil {
+		t.Fatalf("RemoveCompletion() error = %v", err)
+	}
+	if habit.Streak != 2 || habit.LastDone != "2025-01-14" {
+		t.Errorf("RemoveCompletion() streak = %v, lastDone = %v, want 2, 2025-01-14", habit.Streak, habit.LastDone)
+	}
+
+	if err := habit.RemoveCompletion("2025-01-15"); err == nil {
+		t.Error("RemoveCompletion() expected error for missing date")
+	}
+}
+
+func TestHabit_AddAmount(t *testing.T) {
+	habit := Habit{Name: "Water", History: []Completion{{Date: "2025-01-14"}}}
+	if err := habit.SetTarget(8, "glasses"); err != nil {
+		t.Fatalf("SetTarget() error = %v", err)
+	}
+
+	// Days completed before the target was set still count
+	if habit.Streak != 1 || habit.LastDone != "2025-01-14" {
+		t.Errorf("SetTarget() streak = %v, lastDone = %v, want 1, 2025-01-14", habit.Streak, habit.LastDone)
+	}
+
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	if err := habit.AddAmount("2025-01-15", 5, now); err != nil {
+		t.Fatalf("AddAmount() error = %v", err)
+	}
+	if habit.CompletedOn("2025-01-15") {
+		t.Error("AddAmount() day should not count before the target is reached")
+	}
+	if habit.Streak != 1 {
+		t.Errorf("AddAmount() streak = %v, want 1", habit.Streak)
+	}
+
+	if err := habit.AddAmount("2025-01-15", 3, now); err != nil {
+		t.Fatalf("AddAmount() error = %v", err)
+	}
+	if got := habit.AmountOn("2025-01-15"); got != 8 {
+		t.Errorf("AmountOn() = %v, want 8", got)
+	}
+	if !habit.CompletedOn("2025-01-15") || habit.Streak != 2 {
+		t.Errorf("AddAmount() streak = %v, want 2 once the target is reached", habit.Streak)
+	}
+
+	yesNo := Habit{Name: "Exercise"}
+	if err := yesNo.AddAmount("2025-01-15", 1, now); err == nil {
+		t.Error("AddAmount() expected error for habit without target")
+	}
+}
+
+func TestHabit_SetTarget_Invalid(t *testing.T) {
+	for _, target := range []float64{-1, math.NaN(), math.Inf(1)} {
+		habit := Habit{Name: "Water"}
+		if err := habit.SetTarget(target, "glasses"); err == nil {
+			t.Errorf("SetTarget(%v) expected error", target)
+		}
+	}
+
+	for _, s := range []string{"NaN", "Inf", "-inf", "x"} {
+		if _, err := ParseTarget(s); err == nil {
+			t.Errorf("ParseTarget(%q) expected error", s)
+		}
+	}
+	if got, err := ParseTarget("0"); err != nil || got != 0 {
+		t.Errorf("ParseTarget(\"0\") = %v, %v, want 0, nil", got, err)
+	}
+}
+
+func TestHabit_CleanDays(t *testing.T) {
+	today := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		habit Habit
+		want  int
+	}{
+		{
+			name:  "no relapse counts from start",
+			habit: Habit{Name: "Smoking", Kind: KindAvoid, StartDate: "2025-01-01"},
+			want:  14,
+		},
+		{
+			name: "counts from last relapse",
+			habit: Habit{Name: "Smoking", Kind: KindAvoid, StartDate: "2025-01-01",
+				History: []Completion{{Date: "2025-01-05"}, {Date: "2025-01-10"}}},
+			want: 5,
+		},
+		{
+			name: "relapse today",
+			habit: Habit{Name: "Smoking", Kind: KindAvoid, StartDate: "2025-01-01",
+				History: []Completion{{Date: "2025-01-15"}}},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.habit.Recalculate(); err != nil {
+				t.Fatalf("Recalculate() error = %v", err)
+			}
+			if got := tt.habit.CleanDays(today); got != tt.want {
+				t.Errorf("CleanDays() = %v, want %v", got, tt.want)
+			}
+			if got := tt.habit.CurrentStreak(today); got != tt.want {
+				t.Errorf("CurrentStreak() = %v, want %v", got, tt.want)
+			}
+			if tt.habit.IsDue(today) {
+				t.Error("IsDue() avoid habits are never due")
+			}
+		})
+	}
+}
+
+func TestHabit_Notes(t *testing.T) {
+	habit := Habit{Name: "Run", History: []Completion{
+		{Date: "2025-01-13", Note: "Easy 3k"},
+		{Date: "2025-01-14"},
+		{Date: "2025-01-15", Note: "5k in 27min"},
+	}}
+
+	if err := habit.AddNote("2025-01-15", "legs sore"); err != nil {
+		t.Fatalf("AddNote() error = %v", err)
+	}
+	if got := habit.History[2].Note; got != "5k in 27min; legs sore" {
+		t.Errorf("AddNote() note = %q, want appended note", got)
+	}
+	if err := habit.AddNote("2025-01-10", "missing"); err == nil {
+		t.Error("AddNote() expected error for date without entry")
+	}
+
+	matches := habit.NotesMatching("K")
+	if len(matches) != 2 {
+		t.Fatalf("NotesMatching() returned %d entries, want 2", len(matches))
+	}
+	if matches[0].Date != "2025-01-15" {
+		t.Errorf("NotesMatching() first match = %v, want newest first", matches[0].Date)
+	}
+	if len(habit.NotesMatching("swim")) != 0 {
+		t.Error("NotesMatching() expected no matches")
+	}
+}
+
+func TestHabit_Tags(t *testing.T) {
+	habit := Habit{Name: "Running"}
+
+	if err := habit.AddTag(" Health "); err != nil {
+		t.Fatalf("AddTag() error = %v", err)
+	}
+	if err := habit.AddTag("fitness"); err != nil {
+		t.Fatalf("AddTag() error = %v", err)
+	}
+	if err := habit.AddTag("health"); err == nil {
+		t.Error("AddTag() expected error for duplicate tag")
+	}
+	if err := habit.AddTag("two words"); err == nil {
+		t.Error("AddTag() expected error for tag with a space")
+	}
+	if len(habit.Tags) != 2 || habit.Tags[0] != "fitness" || habit.Tags[1] != "health" {
+		t.Errorf("Tags = %v, want [fitness health]", habit.Tags)
+	}
+	if !habit.HasTag("HEALTH") {
+		t.Error("HasTag() should be case-insensitive")
+	}
+	if err := habit.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	if err := habit.RemoveTag("fitness"); err != nil {
+		t.Fatalf("RemoveTag() error = %v", err)
+	}
+	if err := habit.RemoveTag("fitness"); err == nil {
+		t.Error("RemoveTag() expected error for missing tag")
+	}
+	if habit.HasTag("fitness") {
+		t.Error("HasTag() tag should be removed")
+	}
+}
+
+func TestHabitList_FilterByTag(t *testing.T) {
+	habits := HabitList{
+		{Name: "Running", Tags: []string{"health"}},
+		{Name: "Reading", Tags: []string{"learning"}},
+		{Name: "Water", Tags: []string{"health"}},
+	}
+
+	tests := []struct {
+		name string
+		tag  string
+		want int
+	}{
+		{"no filter", "", 3},
+		{"matching tag", "health", 2},
+		{"case-insensitive", "Learning", 1},
+		{"unknown tag", "work", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(habits.FilterByTag(tt.tag)); got != tt.want {
+				t.Errorf("FilterByTag(%q) returned %d habits, want %d", tt.tag, got, tt.want)
+			}
+		})
+	}
+
+	if got := habits.Tags()["health"]; got != 2 {
+		t.Errorf("Tags()[health] = %d, want 2", got)
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// idLength is the number of hex characters in a habit ID.
+const idLength = 8
+
+// minIDPrefix is the shortest ID prefix accepted in place of a habit name.
+const minIDPrefix = 4
+
+// ErrHabitNotFound is returned when no habit matches an ID or name.
+var ErrHabitNotFound = errors.New("habit not found")
+
+// NewID returns a random habit ID.
+func NewID() string {
+	b := make([]byte, idLength/2)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("failed to generate habit ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// legacyID derives an ID from a habit's name, so that habits saved before IDs
+// existed get the same ID every time they are loaded until they are saved.
+func legacyID(name string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(name)))
+	return hex.EncodeToString(sum[:])[:idLength]
+}
+
+// EnsureIDs gives every habit without an ID one derived from its name, and a
+// new random ID to any habit whose ID is already taken.
+func (hl HabitList) EnsureIDs() {
+	seen := make(map[string]bool, len(hl))
+	for i := range hl {
+		id := strings.ToLower(hl[i].ID)
+		if id == "" {
+			id = legacyID(hl[i].Name)
+		}
+		for seen[id] {
+			id = NewID()
+		}
+		hl[i].ID = id
+		seen[id] = true
+	}
+}
+
+// FindByID returns a habit by its exact ID (case-insensitive).
+func (hl HabitList) FindByID(id string) (*Habit, int) {
+	for i, h := range hl {
+		if h.ID != "" && strings.EqualFold(h.ID, id) {
+			return &hl[i], i
+		}
+	}
+	return nil, -1
+}
+
+// Resolve finds the habit a user refers to by ID, name or unique ID prefix
+// (at least four characters), in that order. It returns ErrHabitNotFound if
+// nothing matches.
+func (hl HabitList) Resolve(ref string) (*Habit, int, error) {
+	ref = strings.TrimSpace(ref)
+	if h, i := hl.FindByID(ref); h != nil {
+		return h, i, nil
+	}
+	if h, i := hl.Find(ref); h != nil {
+		return h, i, nil
+	}
+
+	if len(ref) >= minIDPrefix {
+		var match *Habit
+		index := -1
+		for i := range hl {
+			if !strings.HasPrefix(hl[i].ID, strings.ToLower(ref)) {
+				continue
+			}
+			if match != nil {
+				return nil, -1, fmt.Errorf("ID prefix '%s' matches more than one habit", ref)
+			}
+			match, index = &hl[i], i
+		}
+		if match != nil {
+			return match, index, nil
+		}
+	}
+
+	return nil, -1, fmt.Errorf("%w: '%s'", ErrHabitNotFound, ref)
+}
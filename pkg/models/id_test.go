@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHabitList_EnsureIDs(t *testing.T) {
+	habits := HabitList{
+		{Name: "Exercise"},
+		{Name: "Reading", ID: "abcd1234"},
+		{Name: "Writing", ID: "ABCD1234"},
+	}
+	habits.EnsureIDs()
+
+	if habits[0].ID != legacyID("Exercise") {
+		t.Errorf("Expected a name-derived ID, got %q", habits[0].ID)
+	}
+	if habits[1].ID != "abcd1234" {
+		t.Errorf("Expected existing ID to be kept, got %q", habits[1].ID)
+	}
+	if habits[2].ID == habits[1].ID || len(habits[2].ID) != idLength {
+		t.Errorf("Expected duplicate ID to be replaced, got %q", habits[2].ID)
+	}
+
+	again := HabitList{{Name: "exercise"}}
+	again.EnsureIDs()
+	if again[0].ID != habits[0].ID {
+		t.Error("Expected legacy IDs to be stable across loads")
+	}
+}
+
+func TestHabitList_Add_AssignsID(t *testing.T) {
+	var habits HabitList
+	if err := habits.Add(Habit{Name: "Exercise"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := habits.Add(Habit{Name: "Reading", ID: habits[0].ID}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if habits[0].ID == "" || habits[0].ID == habits[1].ID {
+		t.Errorf("Expected unique IDs, got %q and %q", habits[0].ID, habits[1].ID)
+	}
+}
+
+func TestHabitList_Resolve(t *testing.T) {
+	habits := HabitList{
+		{Name: "Exercise", ID: "a1b2c3d4"},
+		{Name: "Reading", ID: "a1b2ffff"},
+		{Name: "a1b2ffff", ID: "0000aaaa"},
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"exact ID", "a1b2c3d4", "Exercise", false},
+		{"ID before name", "a1b2ffff", "Reading", false},
+		{"name", "reading", "Reading", false},
+		{"unique prefix", "a1b2c", "Exercise", false},
+		{"uppercase prefix", "0000AA", "a1b2ffff", false},
+		{"ambiguous prefix", "a1b2", "", true},
+		{"prefix too short", "a1b", "", true},
+		{"unknown", "Meditation", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, err := habits.Resolve(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if !tt.wantErr && h.Name != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.ref, h.Name, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := habits.Resolve("Meditation"); !errors.Is(err, ErrHabitNotFound) {
+		t.Errorf("Expected ErrHabitNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// MergeConflict describes a habit changed on both sides of a merge in ways
+// that could not be combined. The merge keeps our side's version.
+type MergeConflict struct {
+	Habit   string // Name of the habit in the merged list
+	Message string // What was changed on both sides
+}
+
+// String formats the conflict as "Habit: message".
+func (c MergeConflict) String() string {
+	return c.Habit + ": " + c.Message
+}
+
+// Merge combines two versions of a habit list, ours and theirs, that were
+// both changed from a common base, such as the copies of two machines that
+// synced the same file. Habits are matched by ID and completions by day, so
+// marks, amounts and notes recorded on either side are all kept. Habits added
+// on both sides under the same name are treated as one.
+//
+// A field changed differently on both sides, or a habit deleted on one side
+// but changed on the other, is a conflict: ours wins, a deleted habit that
+// was changed is kept, and the conflict is returned for review. An empty base
+// means the versions share no history, so nothing counts as deleted.
+func Merge(base, ours, theirs HabitList) (HabitList, []MergeConflict, error) {
+	baseByID, oursByID, theirsByID := indexByID(base), indexByID(ours), indexByID(theirs)
+
+	// Habits added on both sides under the same name are the same habit,
+	// known by our ID
+	sameAs := make(map[string]string) // Their ID -> our ID
+	for _, t := range theirs {
+		if baseByID[t.ID] != nil || oursByID[t.ID] != nil {
+			continue
+		}
+		if o, _ := ours.Find(t.Name); o != nil && baseByID[o.ID] == nil && theirsByID[o.ID] == nil {
+			sameAs[t.ID] = o.ID
+		}
+	}
+	theirsFor := make(map[string]*Habit, len(theirs)) // By our ID
+	for _, t := range theirs {
+		id := t.ID
+		if ours, ok := sameAs[id]; ok {
+			id = ours
+		}
+		theirsFor[id] = theirsByID[t.ID]
+	}
+
+	var merged HabitList
+	var conflicts []MergeConflict
+	conflict := func(name, format string, args ...any) {
+		conflicts = append(conflicts, MergeConflict{Habit: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	handled := make(map[string]bool) // Their IDs
+	for _, o := range ours {
+		b, t := baseByID[o.ID], theirsFor[o.ID]
+		switch {
+		case t != nil:
+			handled[t.ID] = true
+			if b == nil {
+				b = &Habit{}
+			}
+			habit, messages := mergeHabit(*b, o, *t)
+			for _, m := range messages {
+				conflict(habit.Name, "%s", m)
+			}
+			merged = append(merged, habit)
+		case b == nil:
+			merged = append(merged, o) // Added in ours
+		case !sameHabit(*b, o):
+			conflict(o.Name, "deleted in theirs but changed in ours; kept")
+			merged = append(merged, o)
+		}
+	}
+	for _, t := range theirs {
+		if handled[t.ID] {
+			continue
+		}
+		switch b := baseByID[t.ID]; {
+		case b == nil:
+			merged = append(merged, t) // Added in theirs
+		case !sameHabit(*b, t):
+			conflict(t.Name, "deleted in ours but changed in theirs; kept")
+			merged = append(merged, t)
+		}
+	}
+
+	// Habits renamed or added on different sides may now share a name
+	for i := range merged {
+		name := merged[i].Name
+		for n := 2; merged[:i].Contains(merged[i].Name); n++ {
+			merged[i].Name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		if merged[i].Name != name {
+			conflict(merged[i].Name, "another habit is named '%s'; renamed", name)
+		}
+	}
+
+	for i := range merged {
+		if err := merged[i].Validate(); err != nil {
+			return nil, nil, fmt.Errorf("merged habit '%s' is invalid: %w", merged[i].Name, err)
+		}
+	}
+	return merged, conflicts, nil
+}
+
+// mergeHabit merges two versions of a habit changed from base, returning the
+// merged habit and a description of each conflict.
+func mergeHabit(base, ours, theirs Habit) (Habit, []string) {
+	var conflicts []string
+	field := func(what string, changed bool) {
+		if changed {
+			conflicts = append(conflicts, fmt.Sprintf("%s changed in both ours and theirs; kept ours", what))
+		}
+	}
+
+	merged := ours
+	var nameConflict bool
+	merged.Name, nameConflict = merge3(base.Name, ours.Name, theirs.Name)
+	if nameConflict {
+		conflicts = append(conflicts, fmt.Sprintf("renamed to '%s' in ours and '%s' in theirs; kept ours", ours.Name, theirs.Name))
+	}
+
+	var changed bool
+	merged.Description, changed = merge3(base.Description, ours.Description, theirs.Description)
+	field("description", changed)
+	merged.Color, changed = merge3(base.Color, ours.Color, theirs.Color)
+	field("color", changed)
+	merged.Kind, changed = merge3(base.Kind, ours.Kind, theirs.Kind)
+	field("kind", changed)
+	merged.StartDate, changed = merge3(base.StartDate, ours.StartDate, theirs.StartDate)
+	field("start date", changed)
+	merged.Target, changed = merge3(base.Target, ours.Target, theirs.Target)
+	field("target", changed)
+	merged.Unit, changed = merge3(base.Unit, ours.Unit, theirs.Unit)
+	field("unit", changed)
+	merged.Archived, _ = merge3(base.Archived, ours.Archived, theirs.Archived)
+	if theirs.ResetAt.After(merged.ResetAt) || theirs.ResetAt.Equal(merged.ResetAt) && theirs.ResetDate > merged.ResetDate {
+		// A reset on either side holds
+		merged.ResetAt, merged.ResetDate = theirs.ResetAt, theirs.ResetDate
+	}
+
+	merged.Schedule = ours.Schedule
+	if sameSchedule(ours.Schedule, base.Schedule) {
+		merged.Schedule = theirs.Schedule
+	} else {
+		field("schedule", !sameSchedule(theirs.Schedule, base.Schedule) && !sameSchedule(ours.Schedule, theirs.Schedule))
+	}
+
+	// Freezes are a count, so tokens added or used on both sides add up
+	merged.Freezes = max(ours.Freezes+theirs.Freezes-base.Freezes, 0)
+
+	merged.Tags = mergeSet(base.Tags, ours.Tags, theirs.Tags)
+	merged.Breaks = mergeSet(base.Breaks, ours.Breaks, theirs.Breaks)
+
+	var history []string
+	merged.History, history = mergeHistory(base.History, ours.History, theirs.History)
+	conflicts = append(conflicts, history...)
+
+	if err := merged.Recalculate(); err != nil {
+		// The dates were valid on both sides, so this cannot happen
+		conflicts = append(conflicts, fmt.Sprintf("failed to recalculate streak: %v", err))
+	}
+	return merged, conflicts
+}
+
+// mergeHistory merges completion histories day by day. A day marked,
+// unmarked or given a note or amount on one side takes that side's
+// completion; a day changed on both sides combines them.
+func mergeHistory(base, ours, theirs []Completion) ([]Completion, []string) {
+	byDate := func(history []Completion) map[string]Completion {
+		m := make(map[string]Completion, len(history))
+		for _, c := range history {
+			m[c.Date] = c
+		}
+		return m
+	}
+	b, o, t := byDate(base), byDate(ours), byDate(theirs)
+
+	var dates []string
+	for _, m := range []map[string]Completion{o, t} {
+		for date := range m {
+			if !slices.Contains(dates, date) {
+				dates = append(dates, date)
+			}
+		}
+	}
+	slices.Sort(dates)
+
+	var merged []Completion
+	var conflicts []string
+	for _, date := range dates {
+		bc, inBase := b[date]
+		oc, inOurs := o[date]
+		tc, inTheirs := t[date]
+		oursChanged := inOurs != inBase || inOurs && !sameCompletion(oc, bc)
+		theirsChanged := inTheirs != inBase || inTheirs && !sameCompletion(tc, bc)
+
+		switch {
+		case !theirsChanged:
+			if inOurs {
+				merged = append(merged, oc)
+			}
+		case !oursChanged || inOurs && inTheirs && sameCompletion(oc, tc):
+			if inTheirs {
+				merged = append(merged, tc)
+			}
+		case !inOurs:
+			conflicts = append(conflicts, fmt.Sprintf("%s unmarked in ours but changed in theirs; kept theirs", date))
+			merged = append(merged, tc)
+		case !inTheirs:
+			conflicts = append(conflicts, fmt.Sprintf("%s unmarked in theirs but changed in ours; kept ours", date))
+			merged = append(merged, oc)
+		default:
+			c, conflict := mergeCompletion(bc, oc, tc)
+			if conflict {
+				conflicts = append(conflicts, fmt.Sprintf("%s has different notes in ours and theirs; kept ours", date))
+			}
+			merged = append(merged, c)
+		}
+	}
+	return merged, conflicts
+}
+
+// mergeCompletion combines a day's completion changed on both sides: amounts
+// logged on each side are added up and the earlier timestamp is kept. It
+// reports a conflict if the notes were changed differently.
+func mergeCompletion(base, ours, theirs Completion) (Completion, bool) {
+	merged := ours
+	merged.Amount = max(ours.Amount+theirs.Amount-base.Amount, 0)
+	if merged.Timestamp.IsZero() || !theirs.Timestamp.IsZero() && theirs.Timestamp.Before(merged.Timestamp) {
+		merged.Timestamp = theirs.Timestamp
+	}
+	var conflict bool
+	merged.Note, conflict = merge3(base.Note, ours.Note, theirs.Note)
+	return merged, conflict
+}
+
+// merge3 merges a value changed from base on either side. It reports a
+// conflict, and returns ours, if both sides changed it differently.
+func merge3[T comparable](base, ours, theirs T) (T, bool) {
+	switch {
+	case ours == theirs || theirs == base:
+		return ours, false
+	case ours == base:
+		return theirs, false
+	default:
+		return ours, true
+	}
+}
+
+// MergeVacations combines two versions of the vacations changed from a
+// common base, like Merge: vacations started or planned on either side are
+// added, and vacations ended or cancelled on either side are ended or
+// cancelled.
+func MergeVacations(base, ours, theirs Vacations) Vacations {
+	return mergeSet(base, ours, theirs)
+}
+
+// mergeSet merges lists used as sets: items added on either side are added
+// and items removed on either side are removed, keeping our order.
+func mergeSet[T comparable](base, ours, theirs []T) []T {
+	var merged []T
+	for _, item := range ours {
+		if !slices.Contains(base, item) || slices.Contains(theirs, item) {
+			merged = append(merged, item)
+		}
+	}
+	for _, item := range theirs {
+		if !slices.Contains(base, item) && !slices.Contains(merged, item) {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// indexByID maps the habits of a list by ID, which EnsureIDs has lowercased.
+func indexByID(habits HabitList) map[string]*Habit {
+	index := make(map[string]*Habit, len(habits))
+	for i := range habits {
+		index[habits[i].ID] = &habits[i]
+	}
+	return index
+}
+
+// sameHabit reports whether two versions of a habit are identical.
+func sameHabit(a, b Habit) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// sameSchedule reports whether two schedules are identical.
+func sameSchedule(a, b Schedule) bool {
+	return a.Kind == b.Kind && a.Every == b.Every && a.Times == b.Times && slices.Equal(a.Weekdays, b.Weekdays)
+}
+
+// sameCompletion reports whether two completions are identical.
+func sameCompletion(a, b Completion) bool {
+	return a.Date == b.Date && a.Timestamp.Equal(b.Timestamp) && a.Amount == b.Amount && a.Note == b.Note
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeHabitFor builds a habit with completions on the given dates.
+func mergeHabitFor(id, name string, dates ...string) Habit {
+	habit := Habit{ID: id, Name: name}
+	for _, d := range dates {
+		habit.History = append(habit.History, Completion{Date: d})
+	}
+	habit.Recalculate()
+	return habit
+}
+
+func TestMerge(t *testing.T) {
+	base := HabitList{
+		mergeHabitFor("aaaa0001", "Run", "2025-01-01", "2025-01-02"),
+		mergeHabitFor("aaaa0002", "Read", "2025-01-01"),
+		mergeHabitFor("aaaa0003", "Write"),
+	}
+
+	tests := []struct {
+		name          string
+		ours          func(HabitList) HabitList
+		theirs        func(HabitList) HabitList
+		wantDates     map[string]string // Habit name -> comma-separated dates
+		wantConflicts []string          // Substrings of the conflicts, in order
+	}{
+		{
+			name: "marks on both sides are combined",
+			ours: func(hl HabitList) HabitList {
+				hl[0].AddCompletion("2025-01-03", time.Time{})
+				return hl
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[0].AddCompletion("2025-01-04", time.Time{})
+				hl[1].AddCompletion("2025-01-02", time.Time{})
+				return hl
+			},
+			wantDates: map[string]string{
+				"Run":   "2025-01-01,2025-01-02,2025-01-03,2025-01-04",
+				"Read":  "2025-01-01,2025-01-02",
+				"Write": "",
+			},
+		},
+		{
+			name: "unmark on one side is kept",
+			ours: func(hl HabitList) HabitList {
+				hl[0].RemoveCompletion("2025-01-02")
+				return hl
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[1].AddCompletion("2025-01-02", time.Time{})
+				return hl
+			},
+			wantDates: map[string]string{"Run": "2025-01-01", "Read": "2025-01-01,2025-01-02", "Write": ""},
+		},
+		{
+			name: "rename on one side and marks on the other",
+			ours: func(hl HabitList) HabitList {
+				hl[0].Name = "Jog"
+				return hl
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[0].AddCompletion("2025-01-03", time.Time{})
+				return hl
+			},
+			wantDates: map[string]string{"Jog": "2025-01-01,2025-01-02,2025-01-03", "Read": "2025-01-01", "Write": ""},
+		},
+		{
+			name: "renamed differently on both sides",
+			ours: func(hl HabitList) HabitList {
+				hl[0].Name = "Jog"
+				return hl
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[0].Name = "Sprint"
+				return hl
+			},
+			wantDates:     map[string]string{"Jog": "2025-01-01,2025-01-02", "Read": "2025-01-01", "Write": ""},
+			wantConflicts: []string{"Jog: renamed to 'Jog' in ours and 'Sprint' in theirs"},
+		},
+		{
+			name: "unchanged habit deleted on one side",
+			ours: func(hl HabitList) HabitList {
+				return hl[:2]
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[0].AddCompletion("2025-01-03", time.Time{})
+				return hl
+			},
+			wantDates: map[string]string{"Run": "2025-01-01,2025-01-02,2025-01-03", "Read": "2025-01-01"},
+		},
+		{
+			name: "deleted on one side and marked on the other",
+			ours: func(hl HabitList) HabitList {
+				return hl[1:]
+			},
+			theirs: func(hl HabitList) HabitList {
+				hl[0].AddCompletion("2025-01-03", time.Time{})
+				return hl
+			},
+			wantDates:     map[string]string{"Run": "2025-01-01,2025-01-02,2025-01-03", "Read": "2025-01-01", "Write": ""},
+			wantConflicts: []string{"Run: deleted in ours but changed in theirs"},
+		},
+		{
+			name: "same habit added on both sides",
+			ours: func(hl HabitList) HabitList {
+				return append(hl, mergeHabitFor("bbbb0001", "Stretch", "2025-01-03"))
+			},
+			theirs: func(hl HabitList) HabitList {
+				return append(hl, mergeHabitFor("cccc0001", "stretch", "2025-01-04"))
+			},
+			wantDates: map[string]string{
+				"Run": "2025-01-01,2025-01-02", "Read": "2025-01-01", "Write": "",
+				"Stretch": "2025-01-03,2025-01-04",
+			},
+			wantConflicts: []string{"renamed to 'Stretch' in ours and 'stretch' in theirs"},
+		},
+		{
+			name: "rename onto a name added on the other side",
+			ours: func(hl HabitList) HabitList {
+				hl[2].Name = "Journal"
+				return hl
+			},
+			theirs: func(hl HabitList) HabitList {
+				return append(hl, mergeHabitFor("cccc0001", "Journal", "2025-01-04"))
+			},
+			wantDates: map[string]string{
+				"Run": "2025-01-01,2025-01-02", "Read": "2025-01-01",
+				"Journal": "", "Journal (2)": "2025-01-04",
+			},
+			wantConflicts: []string{"Journal (2): another habit is named 'Journal'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts, err := Merge(base, tt.ours(cloneList(base)), tt.theirs(cloneList(base)))
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+
+			if len(merged) != len(tt.wantDates) {
+				t.Errorf("Merge() returned %d habits, want %d", len(merged), len(tt.wantDates))
+			}
+			for name, want := range tt.wantDates {
+				habit, _ := merged.Find(name)
+				if habit == nil {
+					t.Errorf("habit %q missing after merge", name)
+					continue
+				}
+				var dates []string
+				for _, c := range habit.History {
+					dates = append(dates, c.Date)
+				}
+				if got := strings.Join(dates, ","); got != want {
+					t.Errorf("%s history = %q, want %q", name, got, want)
+				}
+			}
+
+			if len(conflicts) != len(tt.wantConflicts) {
+				t.Fatalf("Merge() conflicts = %v, want %d", conflicts, len(tt.wantConflicts))
+			}
+			for i, want := range tt.wantConflicts {
+				if !strings.Contains(conflicts[i].String(), want) {
+					t.Errorf("conflict %d = %q, want it to contain %q", i, conflicts[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMerge_SameDay(t *testing.T) {
+	base := HabitList{{ID: "aaaa0001", Name: "Water", Target: 8, History: []Completion{{Date: "2025-01-01", Amount: 2}}}}
+
+	ours := cloneList(base)
+	ours[0].History[0].Amount = 5
+	ours[0].History[0].Note = "at work"
+	theirs := cloneList(base)
+	theirs[0].History[0].Amount = 4
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Merge() conflicts = %v, want none", conflicts)
+	}
+	got := merged[0].History[0]
+	if got.Amount != 7 || got.Note != "at work" {
+		t.Errorf("merged completion = %+v, want amount 7 with the note of ours", got)
+	}
+
+	theirs[0].History[0].Note = "at home"
+	if _, conflicts, _ := Merge(base, ours, theirs); len(conflicts) != 1 {
+		t.Errorf("Merge() conflicts = %v, want one for the notes", conflicts)
+	}
+}
+
+func TestMerge_TagsAndFreezes(t *testing.T) {
+	base := HabitList{{ID: "aaaa0001", Name: "Run", Tags: []string{"health", "morning"}, Freezes: 2}}
+
+	ours := cloneList(base)
+	ours[0].Tags = []string{"health", "outdoor"}
+	ours[0].Freezes = 1
+	theirs := cloneList(base)
+	theirs[0].Tags = []string{"health", "morning", "fitness"}
+	theirs[0].Freezes = 4
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Merge() conflicts = %v, want none", conflicts)
+	}
+	if got := strings.Join(merged[0].Tags, ","); got != "health,outdoor,fitness" {
+		t.Errorf("merged tags = %q, want %q", got, "health,outdoor,fitness")
+	}
+	if merged[0].Freezes != 3 {
+		t.Errorf("merged freezes = %d, want 3", merged[0].Freezes)
+	}
+}
+
+func TestMerge_Reset(t *testing.T) {
+	base := HabitList{mergeHabitFor("aaaa0001", "Run", "2025-01-01", "2025-01-02")}
+
+	ours := cloneList(base)
+	ours[0].AddCompletion("2025-01-03", time.Time{})
+	theirs := cloneList(base)
+	theirs[0].ResetStreak(time.Date(2025, 1, 2, 20, 0, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	merged, _, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if len(merged[0].History) != 3 || merged[0].Streak != 1 {
+		t.Errorf("merged = %+v, want all completions and the streak counted from the reset", merged[0])
+	}
+}
+
+// cloneList returns a deep enough copy of a habit list for tests to change.
+func cloneList(hl HabitList) HabitList {
+	clone := make(HabitList, len(hl))
+	for i, h := range hl {
+		h.History = append([]Completion(nil), h.History...)
+		h.Tags = append([]string(nil), h.Tags...)
+		clone[i] = h
+	}
+	return clone
+}
@@ -0,0 +1,334 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleKind identifies how often a habit is meant to be done.
+type ScheduleKind string
+
+// Supported schedule kinds.
+const (
+	ScheduleDaily    ScheduleKind = "daily"    // Every day
+	ScheduleWeekdays ScheduleKind = "weekdays" // Specific days of the week
+	ScheduleInterval ScheduleKind = "interval" // Every N days
+	ScheduleWeekly   ScheduleKind = "weekly"   // N times per week
+	ScheduleMonthly  ScheduleKind = "monthly"  // N times per month
+)
+
+// weekdayNames maps the short names used in schedules to weekdays.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule describes when a habit is due. The zero value is a daily schedule.
+type Schedule struct {
+	Kind     ScheduleKind `json:"kind"`               // Schedule type
+	Weekdays []string     `json:"weekdays,omitempty"` // Short day names ("mon".."sun") for weekday schedules
+	Every    int          `json:"every,omitempty"`    // Days between completions for interval schedules
+	Times    int          `json:"times,omitempty"`    // Completions per period for weekly and monthly schedules
+}
+
+// ParseSchedule parses a schedule specification. Accepted forms are
+// "daily", "weekdays", "weekends", a day list such as "mon,wed,fri",
+// "every 3 days" (or "every:3"), "3/week" and "2/month".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+
+	switch spec {
+	case "", "daily", "everyday", "every day":
+		return Schedule{Kind: ScheduleDaily}, nil
+	case "weekdays":
+		return Schedule{Kind: ScheduleWeekdays, Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}}, nil
+	case "weekends":
+		return Schedule{Kind: ScheduleWeekdays, Weekdays: []string{"sat", "sun"}}, nil
+	}
+
+	// Every N days
+	if rest, ok := strings.CutPrefix(spec, "every"); ok {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+		for _, suffix := range []string{"days", "day", "d"} {
+			rest = strings.TrimSuffix(rest, suffix)
+		}
+		rest = strings.TrimSpace(rest)
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 1 {
+			return Schedule{}, fmt.Errorf("invalid interval in schedule '%s'", spec)
+		}
+		if n == 1 {
+			return Schedule{Kind: ScheduleDaily}, nil
+		}
+		return Schedule{Kind: ScheduleInterval, Every: n}, nil
+	}
+
+	// N times per week or month
+	if count, period, ok := strings.Cut(spec, "/"); ok {
+		count = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(count), "x"))
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return Schedule{}, fmt.Errorf("invalid count in schedule '%s'", spec)
+		}
+		switch strings.TrimSpace(period) {
+		case "week", "wk", "w":
+			if n > 7 {
+				return Schedule{}, fmt.Errorf("a week has only 7 days")
+			}
+			return Schedule{Kind: ScheduleWeekly, Times: n}, nil
+		case "month", "mo", "m":
+			if n > 28 {
+				return Schedule{}, fmt.Errorf("count per month cannot exceed 28")
+			}
+			return Schedule{Kind: ScheduleMonthly, Times: n}, nil
+		}
+		return Schedule{}, fmt.Errorf("unknown period in schedule '%s' (use week or month)", spec)
+	}
+
+	// List of weekdays
+	var days []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.TrimSpace(part)
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		if _, ok := weekdayNames[name]; !ok {
+			return Schedule{}, fmt.Errorf("unknown schedule '%s'", spec)
+		}
+		if !seen[name] {
+			seen[name] = true
+			days = append(days, name)
+		}
+	}
+	return Schedule{Kind: ScheduleWeekdays, Weekdays: days}, nil
+}
+
+// IsDaily reports whether the schedule is a plain daily schedule.
+func (s Schedule) IsDaily() bool {
+	return s.Kind == "" || s.Kind == ScheduleDaily
+}
+
+// Validate checks if the schedule has valid settings for its kind.
+func (s Schedule) Validate() error {
+	switch s.Kind {
+	case "", ScheduleDaily:
+		return nil
+	case ScheduleWeekdays:
+		if len(s.Weekdays) == 0 {
+			return fmt.Errorf("weekday schedule needs at least one day")
+		}
+		for _, d := range s.Weekdays {
+			if _, ok := weekdayNames[d]; !ok {
+				return fmt.Errorf("unknown weekday '%s'", d)
+			}
+		}
+	case ScheduleInterval:
+		if s.Every < 1 {
+			return fmt.Errorf("interval must be at least 1 day")
+		}
+	case ScheduleWeekly, ScheduleMonthly:
+		if s.Times < 1 {
+			return fmt.Errorf("count per period must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unknown schedule kind '%s'", s.Kind)
+	}
+	return nil
+}
+
+// String returns a human-readable description of the schedule.
+func (s Schedule) String() string {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		names := make([]string, len(s.Weekdays))
+		for i, d := range s.Weekdays {
+			names[i] = strings.ToUpper(d[:1]) + d[1:]
+		}
+		return strings.Join(names, ", ")
+	case ScheduleInterval:
+		return fmt.Sprintf("every %d days", s.Every)
+	case ScheduleWeekly:
+		return fmt.Sprintf("%dx per week", s.Times)
+	case ScheduleMonthly:
+		return fmt.Sprintf("%dx per month", s.Times)
+	default:
+		return "daily"
+	}
+}
+
+// Spec returns the schedule in the form ParseSchedule reads, e.g. "3/week".
+func (s Schedule) Spec() string {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		return strings.Join(s.Weekdays, ",")
+	case ScheduleInterval:
+		return fmt.Sprintf("every %d days", s.Every)
+	case ScheduleWeekly:
+		return fmt.Sprintf("%d/week", s.Times)
+	case ScheduleMonthly:
+		return fmt.Sprintf("%d/month", s.Times)
+	default:
+		return "daily"
+	}
+}
+
+// Unit returns the unit streaks are counted in: "day", "completion", "week"
+// or "month". Interval schedules count completions, since the days between
+// them are not due.
+func (s Schedule) Unit() string {
+	switch s.Kind {
+	case ScheduleInterval:
+		return "completion"
+	case ScheduleWeekly:
+		return "week"
+	case ScheduleMonthly:
+		return "month"
+	default:
+		return "day"
+	}
+}
+
+// isPeriodic reports whether the schedule counts completions per week or month.
+func (s Schedule) isPeriodic() bool {
+	return s.Kind == ScheduleWeekly || s.Kind == ScheduleMonthly
+}
+
+// isScheduled reports whether the habit is due on the given day of the week.
+// Only weekday schedules skip days; other kinds treat every day alike.
+func (s Schedule) isScheduled(day time.Time) bool {
+	if s.Kind != ScheduleWeekdays {
+		return true
+	}
+	for _, d := range s.Weekdays {
+		if weekdayNames[d] == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMisses returns how many due days in a row may pass without a
+// completion before a streak breaks.
+func (s Schedule) allowedMisses() int {
+	if s.Kind == ScheduleInterval {
+		return s.Every - 1
+	}
+	return 0
+}
+
+// periodStart returns the first day of the week (Monday) or month containing day.
+func (s Schedule) periodStart(day time.Time) time.Time {
+	if s.Kind == ScheduleMonthly {
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// nextPeriod returns the first day of the period following the one starting at start.
+func (s Schedule) nextPeriod(start time.Time) time.Time {
+	if s.Kind == ScheduleMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// prevPeriod returns the first day of the period preceding the one starting at start.
+func (s Schedule) prevPeriod(start time.Time) time.Time {
+	if s.Kind == ScheduleMonthly {
+		return start.AddDate(0, -1, 0)
+	}
+	return start.AddDate(0, 0, -7)
+}
+
+// streak computes the streak as of the given day from the set of completed
+// dates. The day itself is a grace period: if it has no completion yet (or its
+// week or month has not reached the target yet), the streak is counted up to
+// the day or period before it. Excused days, such as paused ones, neither
+// extend nor break the streak.
+func (s Schedule) streak(done map[string]bool, excused func(time.Time) bool, first, asOf time.Time) int {
+	if len(done) == 0 || asOf.Before(first) {
+		return 0
+	}
+	if s.isPeriodic() {
+		return s.periodStreak(done, excused, first, asOf)
+	}
+
+	streak := 0
+	misses := 0
+	day := asOf
+	if !done[day.Format(DateFormat)] {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for ; !day.Before(first); day = day.AddDate(0, 0, -1) {
+		if done[day.Format(DateFormat)] {
+			streak++
+			misses = 0
+			continue
+		}
+		if !s.isScheduled(day) || excused(day) {
+			continue
+		}
+		misses++
+		if misses > s.allowedMisses() {
+			break
+		}
+	}
+	return streak
+}
+
+// periodStreak counts consecutive weeks or months in which the target number
+// of completions was reached. A period that misses its target is skipped
+// rather than breaking the streak if it contains excused days.
+func (s Schedule) periodStreak(done map[string]bool, excused func(time.Time) bool, first, asOf time.Time) int {
+	streak := 0
+	start := s.periodStart(asOf)
+
+	// The current period only counts once its target is reached
+	if s.countIn(done, start) >= s.Times {
+		streak++
+	}
+
+	for start = s.prevPeriod(start); s.nextPeriod(start).After(first); start = s.prevPeriod(start) {
+		if s.countIn(done, start) >= s.Times {
+			streak++
+			continue
+		}
+		if !s.hasExcused(excused, start) {
+			break
+		}
+	}
+	return streak
+}
+
+// hasExcused reports whether the period starting at start contains an excused day.
+func (s Schedule) hasExcused(excused func(time.Time) bool, start time.Time) bool {
+	end := s.nextPeriod(start)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if excused(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// countIn returns the number of completions in the period starting at start.
+func (s Schedule) countIn(done map[string]bool, start time.Time) int {
+	count := 0
+	end := s.nextPeriod(start)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if done[day.Format(DateFormat)] {
+			count++
+		}
+	}
+	return count
+}
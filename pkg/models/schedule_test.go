@@ -0,0 +1,160 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{name: "daily", spec: "daily", want: "daily"},
+		{name: "empty is daily", spec: "", want: "daily"},
+		{name: "weekdays", spec: "weekdays", want: "Mon, Tue, Wed, Thu, Fri"},
+		{name: "day list", spec: "Mon,wednesday, fri", want: "Mon, Wed, Fri"},
+		{name: "every n days", spec: "every 3 days", want: "every 3 days"},
+		{name: "every short form", spec: "every:2", want: "every 2 days"},
+		{name: "every 1 is daily", spec: "every 1 day", want: "daily"},
+		{name: "per week", spec: "3/week", want: "3x per week"},
+		{name: "per week with x", spec: "3x/week", want: "3x per week"},
+		{name: "per month", spec: "2/month", want: "2x per month"},
+		{name: "too many per week", spec: "8/week", wantErr: true},
+		{name: "unknown period", spec: "3/year", wantErr: true},
+		{name: "unknown day", spec: "mon,funday", wantErr: true},
+		{name: "invalid interval", spec: "every 0 days", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSchedule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseSchedule() = %v, want %v", got.String(), tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if again, err := ParseSchedule(got.Spec()); err != nil || again.String() != tt.want {
+				t.Errorf("ParseSchedule(Spec()) = %v, %v, want %v", again.String(), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHabit_ScheduledStreak(t *testing.T) {
+	weekdays, _ := ParseSchedule("weekdays")
+	everyThree, _ := ParseSchedule("every 3 days")
+	threePerWeek, _ := ParseSchedule("3/week")
+
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		dates      []string
+		today      time.Time
+		wantStreak int
+		wantNow    int
+	}{
+		{
+			// Fri 2025-01-10 and Mon 2025-01-13 with the weekend skipped
+			name:       "weekdays skip weekend",
+			schedule:   weekdays,
+			dates:      []string{"2025-01-09", "2025-01-10", "2025-01-13"},
+			today:      time.Date(2025, 1, 14, 9, 0, 0, 0, time.UTC),
+			wantStreak: 3,
+			wantNow:    3,
+		},
+		{
+			name:       "weekdays missed scheduled day",
+			schedule:   weekdays,
+			dates:      []string{"2025-01-08", "2025-01-10", "2025-01-13"},
+			today:      time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC),
+			wantStreak: 2,
+			wantNow:    0,
+		},
+		{
+			name:       "every three days",
+			schedule:   everyThree,
+			dates:      []string{"2025-01-01", "2025-01-04", "2025-01-06"},
+			today:      time.Date(2025, 1, 9, 9, 0, 0, 0, time.UTC),
+			wantStreak: 3,
+			wantNow:    3,
+		},
+		{
+			name:       "every three days overdue",
+			schedule:   everyThree,
+			dates:      []string{"2025-01-01", "2025-01-04"},
+			today:      time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC),
+			wantStreak: 2,
+			wantNow:    0,
+		},
+		{
+			// Weeks starting 2024-12-30 and 2025-01-06 reach 3, current week in progress
+			name:     "three per week",
+			schedule: threePerWeek,
+			dates: []string{
+				"2024-12-30", "2025-01-01", "2025-01-03",
+				"2025-01-06", "2025-01-08", "2025-01-10",
+				"2025-01-13",
+			},
+			today:      time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC),
+			wantStreak: 2,
+			wantNow:    2,
+		},
+		{
+			name:       "three per week missed a week",
+			schedule:   threePerWeek,
+			dates:      []string{"2024-12-30", "2025-01-01", "2025-01-03", "2025-01-08"},
+			today:      time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC),
+			wantStreak: 1,
+			wantNow:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit := Habit{Name: "Exercise", Schedule: tt.schedule}
+			for _, d := range tt.dates {
+				habit.History = append(habit.History, Completion{Date: d})
+			}
+			if err := habit.Recalculate(); err != nil {
+				t.Fatalf("Recalculate() error = %v", err)
+			}
+			if habit.Streak != tt.wantStreak {
+				t.Errorf("Streak = %v, want %v", habit.Streak, tt.wantStreak)
+			}
+			if got := habit.CurrentStreak(tt.today); got != tt.wantNow {
+				t.Errorf("CurrentStreak() = %v, want %v", got, tt.wantNow)
+			}
+		})
+	}
+}
+
+func TestHabit_IsDue(t *testing.T) {
+	weekdays, _ := ParseSchedule("weekdays")
+	twoPerWeek, _ := ParseSchedule("2/week")
+	saturday := time.Date(2025, 1, 11, 9, 0, 0, 0, time.UTC)
+	monday := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+
+	standup := Habit{Name: "Standup", Schedule: weekdays}
+	if standup.IsDue(saturday) {
+		t.Error("IsDue() weekday habit should not be due on Saturday")
+	}
+	if !standup.IsDue(monday) {
+		t.Error("IsDue() weekday habit should be due on Monday")
+	}
+
+	gym := Habit{Name: "Gym", Schedule: twoPerWeek, History: []Completion{{Date: "2025-01-06"}, {Date: "2025-01-08"}}}
+	if gym.IsDue(saturday) {
+		t.Error("IsDue() weekly habit should not be due once the target is reached")
+	}
+	if done, target := gym.Progress(saturday); done != 2 || target != 2 {
+		t.Errorf("Progress() = %v/%v, want 2/2", done, target)
+	}
+}
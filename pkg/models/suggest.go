@@ -0,0 +1,49 @@
+package models
+
+import "strings"
+
+// Suggest returns the name of the habit that most closely matches name, for
+// "did you mean" hints after a lookup fails. It returns "" if no habit is
+// close enough.
+func (hl HabitList) Suggest(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+
+	// Allow roughly one typo per three characters
+	best, bestDistance := "", len([]rune(name))/3+1
+	for _, h := range hl {
+		candidate := strings.ToLower(h.Name)
+		if strings.HasPrefix(candidate, name) || strings.HasPrefix(name, candidate) {
+			return h.Name
+		}
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = h.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
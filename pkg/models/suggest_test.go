@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestHabitList_Suggest(t *testing.T) {
+	habits := HabitList{
+		{Name: "Exercise"},
+		{Name: "Reading"},
+		{Name: "Drink Water"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"typo", "Excercise", "Exercise"},
+		{"case and typo", "readng", "Reading"},
+		{"prefix", "drink", "Drink Water"},
+		{"too different", "Meditation", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := habits.Suggest(tt.input); got != tt.want {
+				t.Errorf("Suggest(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
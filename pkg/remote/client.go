@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// ErrConflict is returned by Push when the habits on the server changed since
+// the revision the push was based on.
+var ErrConflict = errors.New("habits on the server have changed")
+
+// Client talks to a sync server.
+type Client struct {
+	URL   string       // Base URL of the server, e.g. "http://nas.local:8765"
+	Token string       // Bearer token, if the server needs one
+	HTTP  *http.Client // Client to send requests with; a default with a timeout if nil
+}
+
+// NewClient creates a client for the server at baseURL.
+func NewClient(baseURL, token string) *Client {
+	return &Client{URL: strings.TrimRight(baseURL, "/"), Token: token}
+}
+
+// Pull returns the current habits and vacations on the server.
+func (c *Client) Pull() (Snapshot, error) {
+	var snapshot Snapshot
+	status, err := c.do(http.MethodGet, nil, &snapshot)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if status != http.StatusOK {
+		return Snapshot{}, fmt.Errorf("unexpected response %d from server", status)
+	}
+	snapshot.Habits.EnsureIDs()
+	return snapshot, nil
+}
+
+// Push replaces the habits and vacations on the server with data based on
+// baseRevision. It returns the new snapshot, or ErrConflict and the server's
+// current snapshot if the habits there have changed since baseRevision.
+func (c *Client) Push(baseRevision int64, data storage.Data) (Snapshot, error) {
+	var snapshot Snapshot
+	req := pushRequest{BaseRevision: baseRevision, Habits: data.Habits, Vacations: data.Vacations}
+	status, err := c.do(http.MethodPut, req, &snapshot)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	switch status {
+	case http.StatusOK:
+		return snapshot, nil
+	case http.StatusConflict:
+		return snapshot, ErrConflict
+	default:
+		return Snapshot{}, fmt.Errorf("unexpected response %d from server", status)
+	}
+}
+
+// do sends a request for the habits and decodes the JSON response into out.
+// Error responses other than 409 Conflict are returned as errors.
+func (c *Client) do(method string, body any, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.URL+HabitsPath, reader)
+	if err != nil {
+		return 0, fmt.Errorf("invalid server URL '%s': %w", c.URL, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach sync server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		var failure errorResponse
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil && failure.Error != "" {
+			return 0, fmt.Errorf("sync server: %s", failure.Error)
+		}
+		return 0, fmt.Errorf("sync server: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("invalid response from sync server: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,131 @@
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+func TestServer_PullAndPush(t *testing.T) {
+	store := storage.NewMemoryStorage("server")
+	store.Save(models.HabitList{{ID: "aaaa0001", Name: "Run"}})
+	server := httptest.NewServer(NewServer(store, ""))
+	defer server.Close()
+	client := NewClient(server.URL, "")
+
+	first, err := client.Pull()
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if len(first.Habits) != 1 || first.Habits[0].Name != "Run" {
+		t.Fatalf("Pull() habits = %+v, want Run", first.Habits)
+	}
+
+	// Pulling again without changes keeps the revision
+	again, _ := client.Pull()
+	if again.Revision != first.Revision {
+		t.Errorf("Pull() revision = %d, want unchanged %d", again.Revision, first.Revision)
+	}
+
+	vacations := models.Vacations{{Start: "2025-08-01", End: "2025-08-15", Reason: models.BreakVacation}}
+	pushed, err := client.Push(first.Revision, storage.Data{
+		Habits:    models.HabitList{{ID: "aaaa0001", Name: "Run"}, {ID: "aaaa0002", Name: "Read"}},
+		Vacations: vacations,
+	})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if pushed.Revision == first.Revision {
+		t.Errorf("Push() revision = %d, want a new one", pushed.Revision)
+	}
+	if saved, _ := store.LoadData(); len(saved.Habits) != 2 || !reflect.DeepEqual(saved.Vacations, vacations) {
+		t.Errorf("store has %+v after push, want 2 habits and the vacation", saved)
+	}
+
+	// A push based on the old revision is refused with the current habits
+	current, err := client.Push(first.Revision, storage.Data{})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Push() error = %v, want ErrConflict", err)
+	}
+	if current.Revision != pushed.Revision || len(current.Habits) != 2 || len(current.Vacations) != 1 {
+		t.Errorf("Push() conflict snapshot = %+v, want revision %d with 2 habits and the vacation", current, pushed.Revision)
+	}
+
+	// Changes made to the storage directly get a new revision
+	store.Save(models.HabitList{{ID: "aaaa0001", Name: "Jog"}})
+	changed, _ := client.Pull()
+	if changed.Revision == pushed.Revision || changed.Habits[0].Name != "Jog" {
+		t.Errorf("Pull() = %+v, want Jog at a revision other than %d", changed, pushed.Revision)
+	}
+
+	// A restarted server gives the same habits the same revision
+	restartedServer := httptest.NewServer(NewServer(store, ""))
+	defer restartedServer.Close()
+	restarted, _ := NewClient(restartedServer.URL, "").Pull()
+	if restarted.Revision != changed.Revision {
+		t.Errorf("Pull() after restart revision = %d, want %d", restarted.Revision, changed.Revision)
+	}
+}
+
+func TestServer_Token(t *testing.T) {
+	server := httptest.NewServer(NewServer(storage.NewMemoryStorage("server"), "secret"))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "right token", token: "secret"},
+		{name: "wrong token", token: "guess", wantErr: true},
+		{name: "no token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(server.URL, tt.token).Pull()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Pull() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServer_RejectsBadRequests(t *testing.T) {
+	server := httptest.NewServer(NewServer(storage.NewMemoryStorage("server"), ""))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/v1/other", wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodDelete, path: HabitsPath, wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid JSON", method: http.MethodPut, path: HabitsPath, body: "{", wantStatus: http.StatusBadRequest},
+		{name: "invalid habit", method: http.MethodPut, path: HabitsPath, body: `{"habits":[{"name":""}]}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid vacation", method: http.MethodPut, path: HabitsPath, body: `{"habits":[],"vacations":[{"start":"soon"}]}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package remote implements syncing habits with a server over HTTP.
+//
+// The server serves the habits and vacations of any storage.Storage at
+// /v1/habits. Every version of them it serves has a revision derived from its
+// contents: GET returns the habits with their revision, and PUT replaces them only if the request is
+// based on the current revision, answering 409 Conflict with the current
+// habits otherwise. Clients merge their changes into those and try again.
+package remote
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/storage"
+)
+
+// HabitsPath is the path the habits are served at.
+const HabitsPath = "/v1/habits"
+
+// maxRequestSize bounds the habits a client may upload.
+const maxRequestSize = 16 << 20
+
+// Snapshot is a version of the habits and vacations on the server.
+type Snapshot struct {
+	Revision  int64            `json:"revision"`
+	Habits    models.HabitList `json:"habits"`
+	Vacations models.Vacations `json:"vacations,omitempty"`
+}
+
+// Data returns the habits and vacations of the snapshot.
+func (s Snapshot) Data() storage.Data {
+	return storage.Data{Habits: s.Habits, Vacations: s.Vacations}
+}
+
+// pushRequest is the body of a PUT: the new habits and vacations and the
+// revision they were based on.
+type pushRequest struct {
+	BaseRevision int64            `json:"base_revision"`
+	Habits       models.HabitList `json:"habits"`
+	Vacations    models.Vacations `json:"vacations,omitempty"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// errStale is returned inside an update based on an old revision.
+var errStale = errors.New("stale revision")
+
+// Server serves the habits of a storage to sync clients.
+type Server struct {
+	store storage.Storage
+	token string
+
+	mu sync.Mutex // Serializes requests, so a push is checked and saved as one
+}
+
+// NewServer creates a server for the habits in store. If token is set,
+// requests must carry it as a bearer token.
+//
+// A revision is a hash of the habits, so it needs no state of its own: it
+// stays valid when the server restarts, and a revision seen earlier can only
+// come back with the very same habits. Changes made to the storage by
+// anything but the server, such as commands run on the server's machine,
+// get a revision of their own.
+func NewServer(store storage.Storage, token string) *Server {
+	return &Server{store: store, token: token}
+}
+
+// ServeHTTP handles a sync request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != HabitsPath {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="habit-tracker"`)
+		writeError(w, http.StatusUnauthorized, "missing or wrong token")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.pull(w)
+	case http.MethodPut:
+		s.push(w, r)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// pull returns the current habits.
+func (s *Server) pull(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// push replaces the habits if the request is based on the current revision.
+func (s *Server) push(w http.ResponseWriter, r *http.Request) {
+	var req pushRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if req.Habits == nil {
+		req.Habits = models.HabitList{}
+	}
+	req.Habits.EnsureIDs()
+	for _, habit := range req.Habits {
+		if err := habit.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid habit '%s': %v", habit.Name, err))
+			return
+		}
+	}
+	if err := req.Vacations.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid vacations: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current Snapshot
+	err := s.store.UpdateData(func(data *storage.Data) error {
+		revision, err := revisionOf(*data)
+		if err != nil {
+			return err
+		}
+		current = Snapshot{Revision: revision, Habits: data.Habits, Vacations: data.Vacations}
+		if req.BaseRevision != revision {
+			return errStale
+		}
+		*data = storage.Data{Habits: req.Habits, Vacations: req.Vacations}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errStale):
+		writeJSON(w, http.StatusConflict, current)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save habits: %v", err))
+		return
+	}
+
+	// Read the habits back as the storage keeps them, so the revision is the
+	// one the next pull returns
+	snapshot, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// load returns the habits and vacations in the storage with their revision.
+// The caller must hold s.mu.
+func (s *Server) load() (Snapshot, error) {
+	data, err := s.store.LoadData()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to load habits: %w", err)
+	}
+	revision, err := revisionOf(data)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Revision: revision, Habits: data.Habits, Vacations: data.Vacations}, nil
+}
+
+// revisionOf returns the revision of the habits and vacations: the first 63
+// bits of the SHA-256 hash of their JSON encoding. Habits without vacations
+// keep the revision of the habits alone.
+func revisionOf(data storage.Data) (int64, error) {
+	encoded, err := json.Marshal(data.Habits)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode habits: %w", err)
+	}
+	if len(data.Vacations) > 0 {
+		vacations, err := json.Marshal(data.Vacations)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode vacations: %w", err)
+		}
+		encoded = append(encoded, vacations...)
+	}
+	sum := sha256.Sum256(encoded)
+	return int64(binary.BigEndian.Uint64(sum[:8]) >> 1), nil
+}
+
+// authorized checks the request's bearer token.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix is appended to a data file's path to name the copy of its
+// previous version kept by Save.
+const backupSuffix = ".bak"
+
+// writeFileAtomic replaces the file at path with data so that a crash or a
+// full disk leaves either the old or the new contents, never a mix. The data
+// is written to a temporary file in the same directory, synced, and renamed
+// over the original. The previous version is kept at path + ".bak".
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return replaceFile(path, data, perm, true)
+}
+
+// WriteFile replaces the file at path with data atomically, like saves of the
+// JSON backend, but without keeping the previous version. It is meant for
+// data files that are not the configured storage, such as merge results.
+func WriteFile(path string, data []byte) error {
+	return replaceFile(path, data, 0600, false)
+}
+
+// replaceFile writes data to a temporary file and renames it over path,
+// keeping the previous version at path + ".bak" if keep is set.
+func replaceFile(path string, data []byte, perm os.FileMode, keep bool) (err error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if keep {
+		if err := keepPrevious(path); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// keepPrevious makes path + ".bak" a copy of the current file at path, if any.
+// A hard link is used where possible so the old contents need not be copied.
+func keepPrevious(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	backupPath := path + backupSuffix
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old backup: %w", err)
+	}
+	if err := os.Link(path, backupPath); err == nil {
+		return nil
+	}
+	if err := copyFile(path, backupPath); err != nil {
+		return fmt.Errorf("failed to back up previous version: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies src to dst with the same permissions, syncing dst before
+// returning.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir flushes a directory entry change such as a rename to disk. Not all
+// platforms support syncing directories, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+
+	if err := writeFileAtomic(testFile, []byte("first"), 0600); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if _, err := os.Stat(testFile + backupSuffix); !os.IsNotExist(err) {
+		t.Error("Expected no backup when there was no previous file")
+	}
+
+	if err := writeFileAtomic(testFile, []byte("second"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if err := writeFileAtomic(testFile, []byte("third"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{testFile, "third"},
+		{testFile + backupSuffix, "second"},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", tt.path, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s = %q, want %q", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+
+	info, _ := os.Stat(testFile)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Mode = %v, want the original file's 0600", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the file and its backup, got %d entries", len(entries))
+	}
+}
+
+func TestJSONStorage_SaveKeepsPreviousVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+	store := NewJSONStorage(testFile, Options{})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(models.HabitList{{Name: "Exercise"}, {Name: "Reading"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Habit data is private to the user, and so is its backup
+	for _, path := range []string{testFile, testFile + backupSuffix} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %v, want 0600", filepath.Base(path), info.Mode().Perm())
+		}
+	}
+
+	previous, err := NewJSONStorage(testFile+backupSuffix, Options{}).Load()
+	if err != nil {
+		t.Fatalf("Failed to load backup: %v", err)
+	}
+	if len(previous) != 1 {
+		t.Errorf("Backup has %d habit(s), want 1", len(previous))
+	}
+}
+
+func TestCopyFile_KeepsMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "habits.json")
+	dst := filepath.Join(tmpDir, "habits.json.bak")
+	if err := os.WriteFile(src, []byte("data"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(src, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyFile(src, dst); err != nil {
+		t.Fatalf("copyFile() error = %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Failed to stat copy: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Mode = %v, want the source file's 0640", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,468 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// KDFIterations is the number of PBKDF2-HMAC-SHA256 iterations used to derive
+// a key from the passphrase when encrypting.
+const KDFIterations = 600_000
+
+// maxKDFIterations bounds the iteration count read from encrypted data, so a
+// damaged file cannot make loading take forever.
+const maxKDFIterations = 10_000_000
+
+const (
+	sealedVersion = 1
+	saltSize      = 16
+	keySize       = 32 // AES-256
+	headerSize    = 1 + 4 + saltSize
+
+	// sealedPrefix starts the description of the placeholder habit that holds
+	// the encrypted habits in the wrapped storage.
+	sealedPrefix = "habit-tracker encrypted data:"
+	sealedID     = "00000000"
+	sealedName   = "(encrypted)"
+)
+
+var (
+	// ErrWrongPassphrase is returned when encrypted habits cannot be decrypted
+	// with the given passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase or damaged data")
+
+	// ErrNotEncrypted is returned by EncryptedStorage for habits stored
+	// without encryption.
+	ErrNotEncrypted = errors.New("habits are not encrypted")
+
+	// ErrEncrypted is returned by Encrypt for habits that already are.
+	ErrEncrypted = errors.New("habits are already encrypted")
+)
+
+// PassphraseFunc returns the passphrase to encrypt or decrypt habits with. It
+// is only called when a key is first needed.
+type PassphraseFunc func() ([]byte, error)
+
+// EncryptedStorage wraps another storage, keeping the habits in it encrypted
+// with AES-256-GCM under a key derived from a passphrase with PBKDF2. The
+// wrapped storage holds a single placeholder habit with the encrypted habits
+// and vacations in its description, so any backend can be wrapped and keeps
+// its own locking and atomic saves.
+//
+// Optional encrypted storage only decrypts habits that are encrypted and
+// keeps unencrypted ones as they are, so it can wrap storage without loading
+// it first to find out.
+type EncryptedStorage struct {
+	inner      Storage
+	passphrase PassphraseFunc
+	optional   bool
+
+	mu         sync.Mutex
+	known      bool   // Optional storage: whether the habits were seen yet
+	sealed     bool   // Optional storage: whether they were encrypted
+	secret     []byte // Passphrase, once asked for
+	salt       []byte // Salt of the cached key
+	iterations uint32 // Iteration count of the cached key
+	key        []byte
+}
+
+// NewEncryptedStorage creates storage that encrypts the habits it saves in
+// inner.
+func NewEncryptedStorage(inner Storage, passphrase PassphraseFunc) *EncryptedStorage {
+	return &EncryptedStorage{inner: inner, passphrase: passphrase}
+}
+
+// NewOptionalEncryptedStorage creates storage that decrypts the habits in
+// inner if they are encrypted and passes them through otherwise, saving them
+// the way they were found. The passphrase is only asked for once encrypted
+// habits are read.
+func NewOptionalEncryptedStorage(inner Storage, passphrase PassphraseFunc) *EncryptedStorage {
+	return &EncryptedStorage{inner: inner, passphrase: passphrase, optional: true}
+}
+
+// IsEncrypted reports whether the habits in store are encrypted. For
+// EncryptedStorage, that is whether the habits it wraps are.
+func IsEncrypted(store Storage) (bool, error) {
+	if s, ok := store.(*EncryptedStorage); ok {
+		return s.encrypted()
+	}
+	habits, err := store.Load()
+	if err != nil {
+		return false, err
+	}
+	return isSealed(habits), nil
+}
+
+// IsEncryptedList reports whether habits decoded from a data file, e.g. with
+// Decode, are encrypted habits rather than the habits themselves.
+func IsEncryptedList(habits models.HabitList) bool {
+	return isSealed(habits)
+}
+
+// Encrypt encrypts the habits and vacations in store in place, in one
+// UpdateData so a crash
+// leaves either the old or the new habits and no command run meanwhile is
+// lost. Copies the backend keeps of the unencrypted data, such as JSON's
+// <file>.bak or the event log's history, are removed afterwards; if that was
+// cut short, encrypting again finishes it before returning ErrEncrypted.
+func Encrypt(store Storage, passphrase PassphraseFunc) error {
+	// Derive the key first, so a wrong or missing passphrase leaves the data
+	// untouched and the storage is not locked while it is asked for
+	target := NewEncryptedStorage(store, passphrase)
+	if _, err := target.seal(Data{}); err != nil {
+		return err
+	}
+
+	err := store.UpdateData(func(data *Data) error {
+		if isSealed(data.Habits) {
+			return ErrEncrypted
+		}
+		sealed, err := target.seal(*data)
+		if err != nil {
+			return err
+		}
+		*data = sealed
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrEncrypted) {
+		return err
+	}
+	if purgeErr := purgeHistory(store); purgeErr != nil {
+		return fmt.Errorf("failed to remove unencrypted old versions: %w", purgeErr)
+	}
+	return err
+}
+
+// Decrypt stores the encrypted habits and vacations in store without
+// encryption again, in one UpdateData.
+func Decrypt(store Storage, passphrase PassphraseFunc) error {
+	// Loading derives the key before the storage is locked
+	source := NewEncryptedStorage(store, passphrase)
+	if _, err := source.LoadData(); err != nil {
+		return err
+	}
+
+	return store.UpdateData(func(data *Data) error {
+		opened, err := source.open(*data)
+		if err != nil {
+			return err
+		}
+		*data = opened
+		return nil
+	})
+}
+
+// historyPurger is implemented by backends that keep old versions of the
+// habits beside the current ones.
+type historyPurger interface {
+	purgeHistory() error
+}
+
+// purgeHistory removes the old versions store keeps, if any. Versions kept
+// outside the storage's own files, such as git commits, stay.
+func purgeHistory(store Storage) error {
+	if purger, ok := store.(historyPurger); ok {
+		return purger.purgeHistory()
+	}
+	return nil
+}
+
+// Load decrypts the habits in the wrapped storage, following the vacations.
+func (s *EncryptedStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData decrypts the habits and vacations in the wrapped storage.
+func (s *EncryptedStorage) LoadData() (Data, error) {
+	sealed, err := s.inner.LoadData()
+	if err != nil {
+		return Data{}, err
+	}
+	if !s.observe(sealed.Habits) {
+		return sealed, nil
+	}
+	return s.open(sealed)
+}
+
+// Save encrypts habits into the wrapped storage, keeping the vacations.
+func (s *EncryptedStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData encrypts the habits and vacations into the wrapped storage.
+func (s *EncryptedStorage) SaveData(data Data) error {
+	if s.optional {
+		return s.inner.UpdateData(func(sealed *Data) error {
+			if !s.observe(sealed.Habits) {
+				*sealed = data
+				return nil
+			}
+			var err error
+			*sealed, err = s.seal(data)
+			return err
+		})
+	}
+
+	sealed, err := s.seal(data)
+	if err != nil {
+		return err
+	}
+	return s.inner.SaveData(sealed)
+}
+
+// Update changes the habits like UpdateData.
+func (s *EncryptedStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData decrypts the habits and vacations, passes them to fn and
+// encrypts the result, all within one UpdateData of the wrapped storage.
+// Nothing is saved if fn returns an error, which UpdateData returns
+// unchanged.
+func (s *EncryptedStorage) UpdateData(fn func(*Data) error) error {
+	return s.inner.UpdateData(func(sealed *Data) error {
+		if !s.observe(sealed.Habits) {
+			return fn(sealed)
+		}
+		data, err := s.open(*sealed)
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+		if *sealed, err = s.seal(data); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// Delete deletes the wrapped storage.
+func (s *EncryptedStorage) Delete() error {
+	return s.inner.Delete()
+}
+
+// Exists checks if the wrapped storage exists.
+func (s *EncryptedStorage) Exists() bool {
+	return s.inner.Exists()
+}
+
+// GetPath returns the path of the wrapped storage.
+func (s *EncryptedStorage) GetPath() string {
+	return s.inner.GetPath()
+}
+
+// Encode encrypts habits and vacations into the contents of a JSON data
+// file, for backups that stay encrypted, like the package-level Encode.
+// Optional storage only encrypts them if the habits it wraps are encrypted.
+func (s *EncryptedStorage) Encode(data Data, now time.Time) ([]byte, error) {
+	if encrypted, err := s.encrypted(); err != nil || !encrypted {
+		if err != nil {
+			return nil, err
+		}
+		return Encode(data, now)
+	}
+	sealed, err := s.seal(data)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(sealed, now)
+}
+
+// Decode parses the contents of a JSON data file, decrypting it if it holds
+// encrypted habits. Optional storage whose habits are not encrypted returns
+// encrypted ones as they are, like Decode.
+func (s *EncryptedStorage) Decode(content []byte) (Data, error) {
+	data, err := Decode(content)
+	if err != nil || !isSealed(data.Habits) {
+		return data, err
+	}
+	if encrypted, err := s.encrypted(); err != nil || !encrypted {
+		return data, err
+	}
+	return s.open(data)
+}
+
+// Unwrap returns the wrapped storage.
+func (s *EncryptedStorage) Unwrap() Storage {
+	return s.inner
+}
+
+// encrypted reports whether the wrapped habits are encrypted, loading them if
+// optional storage has not seen them yet.
+func (s *EncryptedStorage) encrypted() (bool, error) {
+	if !s.optional {
+		return true, nil
+	}
+	s.mu.Lock()
+	known, sealed := s.known, s.sealed
+	s.mu.Unlock()
+	if known {
+		return sealed, nil
+	}
+
+	habits, err := s.inner.Load()
+	if err != nil {
+		return false, err
+	}
+	return s.observe(habits), nil
+}
+
+// observe records whether habits read from the wrapped storage are
+// encrypted, and reports whether they are to be decrypted: always, unless
+// the storage is optional.
+func (s *EncryptedStorage) observe(habits models.HabitList) bool {
+	if !s.optional {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known, s.sealed = true, isSealed(habits)
+	return s.sealed
+}
+
+// isSealed reports whether habits is the placeholder holding encrypted habits.
+func isSealed(habits models.HabitList) bool {
+	return len(habits) == 1 && strings.HasPrefix(habits[0].Description, sealedPrefix)
+}
+
+// seal encrypts the habits and vacations into the placeholder habit list.
+func (s *EncryptedStorage) seal(data Data) (Data, error) {
+	// Only the habits and vacations are read back; the outer data file has
+	// the metadata
+	plaintext, err := encode(data, Metadata{})
+	if err != nil {
+		return Data{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the key of the loaded data, so saving does not derive another one
+	if s.key == nil {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return Data{}, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		if err := s.deriveKey(salt, KDFIterations); err != nil {
+			return Data{}, err
+		}
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, sealedVersion)
+	header = binary.BigEndian.AppendUint32(header, s.iterations)
+	header = append(header, s.salt...)
+
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return Data{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Data{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The header is authenticated along with the habits
+	ciphertext := append(header, nonce...)
+	ciphertext = aead.Seal(ciphertext, nonce, plaintext, header)
+
+	return Data{Habits: models.HabitList{{
+		ID:          sealedID,
+		Name:        sealedName,
+		Description: sealedPrefix + base64.StdEncoding.EncodeToString(ciphertext),
+	}}}, nil
+}
+
+// open decrypts the placeholder habit list. Empty storage holds no habits.
+func (s *EncryptedStorage) open(sealed Data) (Data, error) {
+	if len(sealed.Habits) == 0 {
+		return Data{Habits: models.HabitList{}, Vacations: sealed.Vacations}, nil
+	}
+	if !isSealed(sealed.Habits) {
+		return Data{}, fmt.Errorf("%w; run 'habit encrypt' first", ErrNotEncrypted)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed.Habits[0].Description, sealedPrefix))
+	if err != nil || len(data) < headerSize {
+		return Data{}, ErrWrongPassphrase
+	}
+	if data[0] != sealedVersion {
+		return Data{}, fmt.Errorf("%w (encryption format %d)", ErrNewerSchema, data[0])
+	}
+	header, rest := data[:headerSize], data[headerSize:]
+	iterations := binary.BigEndian.Uint32(header[1:5])
+	salt := header[5:]
+	if iterations == 0 || iterations > maxKDFIterations {
+		return Data{}, ErrWrongPassphrase
+	}
+
+	s.mu.Lock()
+	if s.key == nil || s.iterations != iterations || !bytes.Equal(s.salt, salt) {
+		err = s.deriveKey(bytes.Clone(salt), iterations)
+	}
+	key := s.key
+	s.mu.Unlock()
+	if err != nil {
+		return Data{}, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return Data{}, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return Data{}, ErrWrongPassphrase
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return Data{}, ErrWrongPassphrase
+	}
+	return Decode(plaintext)
+}
+
+// deriveKey derives and caches the key for a salt, asking for the passphrase
+// the first time. The caller must hold s.mu.
+func (s *EncryptedStorage) deriveKey(salt []byte, iterations uint32) error {
+	if s.secret == nil {
+		secret, err := s.passphrase()
+		if err != nil {
+			return err
+		}
+		if len(secret) == 0 {
+			return errors.New("the passphrase must not be empty")
+		}
+		s.secret = secret
+	}
+
+	key, err := pbkdf2.Key(sha256.New, string(s.secret), salt, int(iterations), keySize)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+	s.key, s.salt, s.iterations = key, salt, iterations
+	return nil
+}
+
+// newAEAD creates the AES-GCM cipher for a key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func passphrase(secret string) PassphraseFunc {
+	return func() ([]byte, error) { return []byte(secret), nil }
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.json")
+	inner := NewJSONStorage(path, Options{})
+
+	// Saving twice leaves a previous version in <file>.bak
+	if err := inner.Save(models.HabitList{{Name: "Therapy", History: []models.Completion{{Date: "2025-01-15", Note: "private"}}}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := inner.Save(models.HabitList{{Name: "Therapy", History: []models.Completion{{Date: "2025-01-15", Note: "private"}}}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// And an upgrade from an older version left the original beside it
+	if err := os.WriteFile(path+".v1.bak", []byte(`[{"name": "Therapy", "description": "private"}]`), 0600); err != nil {
+		t.Fatalf("Failed to write schema backup: %v", err)
+	}
+
+	if _, err := NewEncryptedStorage(inner, passphrase("secret")).Load(); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Load() of unencrypted habits error = %v, want ErrNotEncrypted", err)
+	}
+
+	if err := Encrypt(inner, passphrase("secret")); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if err := Encrypt(inner, passphrase("secret")); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Encrypt() twice error = %v, want ErrEncrypted", err)
+	}
+
+	// Neither the data file nor the kept old versions have the plaintext
+	for _, file := range []string{path, path + backupSuffix, path + ".v1.bak"} {
+		if data, err := os.ReadFile(file); err == nil && bytes.Contains(data, []byte("private")) {
+			t.Errorf("%s contains unencrypted habits", filepath.Base(file))
+		}
+	}
+	if encrypted, err := IsEncrypted(inner); err != nil || !encrypted {
+		t.Errorf("IsEncrypted() = %v, %v; want true", encrypted, err)
+	}
+
+	store := NewEncryptedStorage(inner, passphrase("secret"))
+	if err := store.Update(func(habits *models.HabitList) error {
+		return habits.Add(models.Habit{Name: "Reading"})
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	habits, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(habits) != 2 || habits[0].History[0].Note != "private" {
+		t.Errorf("Load() = %+v, want both habits with their history", habits)
+	}
+
+	if _, err := NewEncryptedStorage(inner, passphrase("wrong")).Load(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load() with the wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	if err := Decrypt(inner, passphrase("secret")); err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	habits, err = inner.Load()
+	if err != nil || len(habits) != 2 {
+		t.Errorf("Load() after Decrypt() = %+v, %v; want 2 habits", habits, err)
+	}
+}
+
+func TestEncrypt_RemovesPlaintext(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(dir string) Storage
+	}{
+		{"json", func(dir string) Storage { return NewJSONStorage(filepath.Join(dir, "habits.json"), Options{}) }},
+		{"events", func(dir string) Storage {
+			store := NewEventStorage(filepath.Join(dir, "habits.jsonl"), Options{})
+			store.SetCompactEvery(2) // Archive some events
+			return store
+		}},
+		{"sqlite", func(dir string) Storage { return NewSQLiteStorage(filepath.Join(dir, "habits.db"), Options{}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := tt.open(dir)
+			for _, note := range []string{"private", "private too", "private again"} {
+				if err := store.Save(models.HabitList{{ID: "aaaa0001", Name: "Therapy",
+					History: []models.Completion{{Date: "2025-01-15", Note: note}}}}); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			if err := Encrypt(store, passphrase("secret")); err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+
+			files, _ := os.ReadDir(dir)
+			for _, file := range files {
+				data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+				if err == nil && bytes.Contains(data, []byte("private")) {
+					t.Errorf("%s contains unencrypted habits", file.Name())
+				}
+			}
+			habits, err := NewEncryptedStorage(store, passphrase("secret")).Load()
+			if err != nil || len(habits) != 1 || habits[0].History[0].Note != "private again" {
+				t.Errorf("Load() = %+v, %v; want the habit as last saved", habits, err)
+			}
+		})
+	}
+}
+
+func TestOptionalEncryptedStorage(t *testing.T) {
+	inner := NewJSONStorage(filepath.Join(t.TempDir(), "habits.json"), Options{})
+	asked := 0
+	secret := func() ([]byte, error) {
+		asked++
+		return []byte("secret"), nil
+	}
+
+	// Unencrypted habits pass through without asking for the passphrase
+	store := NewOptionalEncryptedStorage(inner, secret)
+	if err := store.Save(models.HabitList{{Name: "Run"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Update(func(habits *models.HabitList) error {
+		return habits.Add(models.Habit{Name: "Read"})
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if habits, err := inner.Load(); err != nil || len(habits) != 2 {
+		t.Errorf("inner Load() = %+v, %v; want 2 unencrypted habits", habits, err)
+	}
+	if encrypted, err := IsEncrypted(store); err != nil || encrypted {
+		t.Errorf("IsEncrypted() = %v, %v; want false", encrypted, err)
+	}
+	if data, _ := store.Encode(Data{Habits: models.HabitList{{Name: "Run"}}}, time.Time{}); !bytes.Contains(data, []byte("Run")) {
+		t.Errorf("Encode() = %s, want unencrypted habits", data)
+	}
+	if asked != 0 {
+		t.Errorf("passphrase asked for %d time(s), want 0", asked)
+	}
+
+	// Encrypted habits are decrypted and stay encrypted when saved
+	if err := Encrypt(inner, passphrase("secret")); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	store = NewOptionalEncryptedStorage(inner, secret)
+	if err := store.Save(models.HabitList{{Name: "Walk"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if encrypted, err := IsEncrypted(inner); err != nil || !encrypted {
+		t.Errorf("IsEncrypted(inner) = %v, %v; want true after Save()", encrypted, err)
+	}
+	if habits, err := store.Load(); err != nil || len(habits) != 1 || habits[0].Name != "Walk" {
+		t.Errorf("Load() = %+v, %v; want Walk", habits, err)
+	}
+	if data, _ := store.Encode(Data{Habits: models.HabitList{{Name: "Walk"}}}, time.Time{}); bytes.Contains(data, []byte("Walk")) {
+		t.Errorf("Encode() = %s, want encrypted habits", data)
+	}
+	if asked != 1 {
+		t.Errorf("passphrase asked for %d time(s), want 1", asked)
+	}
+}
+
+func TestEncryptedStorage_DetectsTampering(t *testing.T) {
+	inner := NewMemoryStorage("test")
+	store := NewEncryptedStorage(inner, passphrase("secret"))
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	sealed, _ := inner.Load()
+	data := []byte(sealed[0].Description)
+	data[len(data)-5] ^= 1
+	sealed[0].Description = string(data)
+	inner.Save(sealed)
+
+	if _, err := store.Load(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load() of changed data error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestEncryptedStorage_EncodeDecode(t *testing.T) {
+	store := NewEncryptedStorage(NewMemoryStorage("test"), passphrase("secret"))
+	vacations := models.Vacations{{Start: "2025-08-01", End: "2025-08-15", Reason: models.BreakVacation}}
+	data, err := store.Encode(Data{Habits: models.HabitList{{Name: "Exercise"}}, Vacations: vacations}, time.Time{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if bytes.Contains(data, []byte("Exercise")) || bytes.Contains(data, []byte("2025-08-01")) {
+		t.Error("Encode() wrote unencrypted habits or vacations")
+	}
+
+	decoded, err := store.Decode(data)
+	if err != nil || len(decoded.Habits) != 1 || decoded.Habits[0].Name != "Exercise" || !reflect.DeepEqual(decoded.Vacations, vacations) {
+		t.Errorf("Decode() = %+v, %v; want the encoded habit and vacation", decoded, err)
+	}
+
+	plain, _ := Encode(Data{Habits: models.HabitList{{Name: "Reading"}}}, time.Time{})
+	if decoded, err := store.Decode(plain); err != nil || decoded.Habits[0].Name != "Reading" {
+		t.Errorf("Decode() of an unencrypted file = %+v, %v", decoded, err)
+	}
+}
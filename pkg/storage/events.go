@@ -0,0 +1,562 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// DefaultCompactEvery is how many events EventStorage appends after the last
+// snapshot before it compacts the log.
+const DefaultCompactEvery = 1000
+
+// archiveSuffix is appended to an event log's path to name the file that
+// compaction moves old events to.
+const archiveSuffix = ".archive"
+
+// EventType identifies what an event records.
+type EventType string
+
+// Event types. Each change to a habit is recorded as the smallest event that
+// describes it; anything else is recorded as an update or a snapshot.
+const (
+	EventSnapshot  EventType = "snapshot"  // The complete habit list and vacations
+	EventCreate    EventType = "create"    // A new habit, with its history
+	EventDelete    EventType = "delete"    // A habit was deleted
+	EventRename    EventType = "rename"    // A habit got a new name
+	EventMark      EventType = "mark"      // A completion was added or changed
+	EventUnmark    EventType = "unmark"    // A completion was removed
+	EventReset     EventType = "reset"     // A habit's streak was reset
+	EventUpdate    EventType = "update"    // Other fields of a habit changed
+	EventVacations EventType = "vacations" // Vacations were started, ended or planned
+)
+
+// Event is one line of an event log.
+type Event struct {
+	Seq        int64              `json:"seq"`
+	Time       time.Time          `json:"time"`
+	Type       EventType          `json:"type"`
+	HabitID    string             `json:"habit_id,omitempty"`
+	Name       string             `json:"name,omitempty"`       // Habit name, new name for renames
+	Date       string             `json:"date,omitempty"`       // Day of a mark or unmark
+	Completion *models.Completion `json:"completion,omitempty"` // Completion recorded by a mark
+	Habit      *models.Habit      `json:"habit,omitempty"`      // Habit for create and update, changed fields otherwise
+	Habits     models.HabitList   `json:"habits,omitempty"`     // Habit list for snapshots
+	Vacations  models.Vacations   `json:"vacations,omitempty"`  // All vacations, for snapshots and vacation events
+}
+
+// EventStorage implements habit storage as an append-only JSON Lines log of
+// events. The habit list is rebuilt by replaying the log, so every mark,
+// unmark, rename, reset and delete stays on record. Once the log grows long,
+// it is compacted into a single snapshot and the replaced events are moved to
+// <file>.archive, which keeps the full audit trail.
+type EventStorage struct {
+	filePath     string
+	lockTimeout  time.Duration
+	clock        models.Clock
+	compactEvery int
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendEvents,
+		Description: "append-only event log",
+		Extension:   ".jsonl",
+		Open:        func(location string, opts Options) (Storage, error) { return NewEventStorage(location, opts), nil },
+	})
+}
+
+// NewEventStorage creates a new event log storage instance.
+func NewEventStorage(filePath string, opts Options) *EventStorage {
+	return &EventStorage{
+		filePath:     filePath,
+		lockTimeout:  opts.lockTimeout(),
+		clock:        opts.clock(),
+		compactEvery: DefaultCompactEvery,
+	}
+}
+
+// SetLockTimeout sets how long to wait for another process to release the log.
+func (s *EventStorage) SetLockTimeout(timeout time.Duration) {
+	s.lockTimeout = timeout
+}
+
+// SetCompactEvery sets after how many events since the last snapshot the log
+// is compacted. Zero or less turns automatic compaction off.
+func (s *EventStorage) SetCompactEvery(n int) {
+	s.compactEvery = n
+}
+
+// Load rebuilds the habits by replaying the event log, following its
+// vacations.
+func (s *EventStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData rebuilds the habits and vacations by replaying the event log.
+func (s *EventStorage) LoadData() (Data, error) {
+	if !s.Exists() {
+		return Data{Habits: models.HabitList{}}, nil
+	}
+
+	var data Data
+	err := s.withLock(false, func() error {
+		events, _, err := readEvents(s.filePath)
+		if err != nil {
+			return err
+		}
+		data, err = replay(events)
+		return err
+	})
+	if err != nil {
+		return Data{}, err
+	}
+	return data, nil
+}
+
+// Save appends the events that turn the stored habits into the given ones.
+func (s *EventStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData appends the events that turn the stored habits and vacations into
+// the given ones.
+func (s *EventStorage) SaveData(data Data) error {
+	return s.UpdateData(func(current *Data) error {
+		*current = data
+		return nil
+	})
+}
+
+// Update changes the habits like UpdateData.
+func (s *EventStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData replays the log, passes the habits and vacations to fn and
+// appends the events for whatever fn changed, holding the exclusive lock
+// throughout. Nothing is written if fn returns an error, which UpdateData
+// returns unchanged.
+func (s *EventStorage) UpdateData(fn func(*Data) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return s.withLock(true, func() error {
+		events, validSize, err := readEvents(s.filePath)
+		if err != nil {
+			return err
+		}
+		old, err := replay(events)
+		if err != nil {
+			return err
+		}
+
+		// fn changes completions in place, so it gets its own copy to diff
+		// against old
+		data := cloneData(old)
+		if err := fn(&data); err != nil {
+			return err
+		}
+		data.Habits = slices.Clone(data.Habits)
+		data.Habits.EnsureIDs()
+
+		seq := int64(0)
+		if len(events) > 0 {
+			seq = events[len(events)-1].Seq
+		}
+		changes := diff(old, data, seq, s.clock.Now())
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := appendEvents(s.filePath, validSize, changes); err != nil {
+			return err
+		}
+
+		if s.compactEvery > 0 && sinceSnapshot(events)+len(changes) >= s.compactEvery {
+			return s.compact(append(events, changes...))
+		}
+		return nil
+	})
+}
+
+// Compact replaces the log with a single snapshot of the current habits,
+// moving the replaced events to <file>.archive.
+func (s *EventStorage) Compact() error {
+	if !s.Exists() {
+		return nil
+	}
+	return s.withLock(true, func() error {
+		events, _, err := readEvents(s.filePath)
+		if err != nil {
+			return err
+		}
+		return s.compact(events)
+	})
+}
+
+// purgeHistory replaces the log with a single snapshot of the current habits
+// like Compact, but drops the replaced events and the archive instead of
+// keeping them.
+func (s *EventStorage) purgeHistory() error {
+	if !s.Exists() {
+		return nil
+	}
+	return s.withLock(true, func() error {
+		events, _, err := readEvents(s.filePath)
+		if err != nil {
+			return err
+		}
+		if err := s.writeSnapshot(events); err != nil {
+			return err
+		}
+		for _, path := range []string{s.filePath + backupSuffix, s.filePath + archiveSuffix} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Events returns every recorded event, archived ones first.
+func (s *EventStorage) Events() ([]Event, error) {
+	var all []Event
+	err := s.withLock(false, func() error {
+		for _, path := range []string{s.filePath + archiveSuffix, s.filePath} {
+			events, _, err := readEvents(path)
+			if err != nil {
+				return err
+			}
+			all = append(all, events...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// Delete removes the event log and its archive.
+func (s *EventStorage) Delete() error {
+	for _, path := range []string{s.filePath, s.filePath + archiveSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists checks if the event log exists.
+func (s *EventStorage) Exists() bool {
+	_, err := os.Stat(s.filePath)
+	return err == nil
+}
+
+// GetPath returns the event log path.
+func (s *EventStorage) GetPath() string {
+	return s.filePath
+}
+
+// withLock runs fn while holding the lock on the event log.
+func (s *EventStorage) withLock(exclusive bool, fn func() error) error {
+	if !exclusive && !s.Exists() {
+		return fn()
+	}
+	lock, err := acquireLock(s.filePath+lockSuffix, exclusive, s.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	return fn()
+}
+
+// compact archives all but a new snapshot event. The caller must hold the
+// exclusive lock.
+func (s *EventStorage) compact(events []Event) error {
+	// The archive is only appended to, so a crash between the two writes at
+	// worst leaves events recorded twice there, never lost
+	if err := appendEvents(s.filePath+archiveSuffix, -1, events); err != nil {
+		return fmt.Errorf("failed to archive events: %w", err)
+	}
+	if err := s.writeSnapshot(events); err != nil {
+		return err
+	}
+	// The previous log is already in the archive
+	os.Remove(s.filePath + backupSuffix)
+	return nil
+}
+
+// writeSnapshot replaces the log with a snapshot of the habits and vacations
+// events lead to. The caller must hold the exclusive lock.
+func (s *EventStorage) writeSnapshot(events []Event) error {
+	data, err := replay(events)
+	if err != nil {
+		return err
+	}
+
+	snapshot := Event{Type: EventSnapshot, Time: s.clock.Now(), Habits: data.Habits, Vacations: data.Vacations}
+	if len(events) > 0 {
+		snapshot.Seq = events[len(events)-1].Seq + 1
+	}
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := writeFileAtomic(s.filePath, append(line, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// readEvents parses an event log. It also returns the size of its complete
+// lines: a last line without a newline is a write cut short by a crash and is
+// ignored, and the next append overwrites it.
+func readEvents(path string) ([]Event, int64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	validSize := int64(bytes.LastIndexByte(data, '\n') + 1)
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data[:validSize]))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, 0, fmt.Errorf("invalid event on line %d of %s: %w", line, path, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, validSize, nil
+}
+
+// appendEvents writes events to the end of the log at path, after cutting off
+// anything past validSize (unless it is negative), and syncs the file.
+func appendEvents(path string, validSize int64, events []Event) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	if validSize >= 0 {
+		if err := file.Truncate(validSize); err != nil {
+			return fmt.Errorf("failed to repair event log: %w", err)
+		}
+	}
+	if _, err := file.Seek(0, 2); err != nil {
+		return fmt.Errorf("failed to append to event log: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to append to event log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event log: %w", err)
+	}
+	return file.Close()
+}
+
+// sinceSnapshot counts the events after the last snapshot.
+func sinceSnapshot(events []Event) int {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == EventSnapshot {
+			return len(events) - 1 - i
+		}
+	}
+	return len(events)
+}
+
+// replay rebuilds the habit list and vacations from events.
+func replay(events []Event) (Data, error) {
+	data := Data{Habits: models.HabitList{}}
+	for _, event := range events {
+		if err := apply(&data, event); err != nil {
+			return Data{}, fmt.Errorf("failed to replay event %d: %w", event.Seq, err)
+		}
+	}
+	return data, nil
+}
+
+// apply changes the habits or vacations as recorded by one event.
+func apply(data *Data, event Event) error {
+	habits := &data.Habits
+	switch event.Type {
+	case EventSnapshot:
+		*habits = slices.Clone(event.Habits)
+		if *habits == nil {
+			*habits = models.HabitList{}
+		}
+		data.Vacations = slices.Clone(event.Vacations)
+		return nil
+	case EventVacations:
+		data.Vacations = slices.Clone(event.Vacations)
+		return nil
+	case EventCreate:
+		if event.Habit == nil {
+			return errors.New("create event without a habit")
+		}
+		*habits = append(*habits, cloneHabit(*event.Habit))
+		return nil
+	}
+
+	habit, index := habits.FindByID(event.HabitID)
+	if habit == nil {
+		return fmt.Errorf("unknown habit %s", event.HabitID)
+	}
+
+	switch event.Type {
+	case EventDelete:
+		return habits.Remove(index)
+	case EventUpdate, EventReset:
+		if event.Habit == nil {
+			return fmt.Errorf("%s event without a habit", event.Type)
+		}
+	case EventRename:
+		habit.Name = event.Name
+	case EventMark:
+		if event.Completion == nil {
+			return errors.New("mark event without a completion")
+		}
+		history := slices.DeleteFunc(slices.Clone(habit.History), func(c models.Completion) bool {
+			return c.Date == event.Completion.Date
+		})
+		history = append(history, *event.Completion)
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+		habit.History = history
+	case EventUnmark:
+		habit.History = slices.DeleteFunc(slices.Clone(habit.History), func(c models.Completion) bool {
+			return c.Date == event.Date
+		})
+	default:
+		return fmt.Errorf("%w: unknown event type '%s'", ErrNewerSchema, event.Type)
+	}
+
+	// Any event may also carry the habit's other fields, such as the streak
+	// a mark changed
+	if event.Habit != nil {
+		updated := cloneHabit(*event.Habit)
+		updated.ID, updated.Name, updated.History = habit.ID, habit.Name, habit.History
+		*habit = updated
+	}
+	return nil
+}
+
+// diff returns the events that turn old into data, numbered after seq.
+func diff(old, data Data, seq int64, now time.Time) []Event {
+	var events []Event
+	add := func(event Event) {
+		seq++
+		event.Seq, event.Time = seq, now
+		events = append(events, event)
+	}
+
+	habits := data.Habits
+	for _, h := range old.Habits {
+		if existing, _ := habits.FindByID(h.ID); existing == nil {
+			add(Event{Type: EventDelete, HabitID: h.ID, Name: h.Name})
+		}
+	}
+
+	for _, h := range habits {
+		before, _ := old.Habits.FindByID(h.ID)
+		if before == nil {
+			created := cloneHabit(h)
+			add(Event{Type: EventCreate, HabitID: h.ID, Name: h.Name, Habit: &created})
+			continue
+		}
+
+		if h.Name != before.Name {
+			add(Event{Type: EventRename, HabitID: h.ID, Name: h.Name})
+		}
+
+		history := before.History
+		for _, c := range history {
+			if !h.HasEntry(c.Date) {
+				add(Event{Type: EventUnmark, HabitID: h.ID, Name: h.Name, Date: c.Date})
+			}
+		}
+		for _, c := range h.History {
+			i := slices.IndexFunc(history, func(p models.Completion) bool { return p.Date == c.Date })
+			if i < 0 || !jsonEqual(history[i], c) {
+				completion := c
+				add(Event{Type: EventMark, HabitID: h.ID, Name: h.Name, Date: c.Date, Completion: &completion})
+			}
+		}
+
+		// Everything else, such as the streak derived from the history, goes
+		// with the last event of the habit, or in an update of its own
+		a, b := cloneHabit(*before), cloneHabit(h)
+		a.Name, a.History, b.History = b.Name, nil, nil
+		if h.ResetAt.After(before.ResetAt) || h.ResetDate != before.ResetDate {
+			add(Event{Type: EventReset, HabitID: h.ID, Name: h.Name, Habit: &b})
+		} else if !jsonEqual(a, b) {
+			if n := len(events); n > 0 && events[n-1].HabitID == h.ID && events[n-1].Type != EventDelete {
+				events[n-1].Habit = &b
+			} else {
+				add(Event{Type: EventUpdate, HabitID: h.ID, Name: h.Name, Habit: &b})
+			}
+		}
+	}
+
+	if !slices.Equal(old.Vacations, data.Vacations) {
+		add(Event{Type: EventVacations, Vacations: slices.Clone(data.Vacations)})
+	}
+
+	// Anything the events above cannot express, such as a new order, is
+	// recorded as a snapshot instead
+	start := Event{Type: EventSnapshot, Habits: old.Habits, Vacations: old.Vacations}
+	if replayed, err := replay(append([]Event{start}, events...)); err != nil || !jsonEqual(replayed.Habits, habits) {
+		events, seq = nil, seq-int64(len(events))
+		add(Event{Type: EventSnapshot, Habits: slices.Clone(habits), Vacations: slices.Clone(data.Vacations)})
+	}
+	return events
+}
+
+// jsonEqual reports whether two values are stored the same way, which treats
+// nil and empty slices alike and ignores monotonic clock readings.
+func jsonEqual(a, b any) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(x, y)
+}
+
+// cloneHabit copies a habit so that later changes to its slices do not alter
+// recorded events.
+func cloneHabit(h models.Habit) models.Habit {
+	h.History = slices.Clone(h.History)
+	h.Tags = slices.Clone(h.Tags)
+	h.Breaks = slices.Clone(h.Breaks)
+	h.Schedule.Weekdays = slices.Clone(h.Schedule.Weekdays)
+	return h
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func eventTypes(events []Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestEventStorage_RecordsChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewEventStorage(filepath.Join(tmpDir, "habits.jsonl"), Options{})
+
+	steps := []struct {
+		name   string
+		change func(habits *models.HabitList) error
+		want   []EventType
+	}{
+		{"add", func(habits *models.HabitList) error {
+			return habits.Add(models.Habit{Name: "Exercise"})
+		}, []EventType{EventCreate}},
+		{"mark", func(habits *models.HabitList) error {
+			(*habits)[0].History = append((*habits)[0].History, models.Completion{Date: "2025-01-15", Note: "5k"})
+			(*habits)[0].Streak, (*habits)[0].LastDone = 1, "2025-01-15"
+			return nil
+		}, []EventType{EventMark}},
+		{"mark again", func(habits *models.HabitList) error {
+			(*habits)[0].History = append([]models.Completion{{Date: "2025-01-14"}}, (*habits)[0].History...)
+			return nil
+		}, []EventType{EventMark}},
+		{"unmark", func(habits *models.HabitList) error {
+			(*habits)[0].History = (*habits)[0].History[1:]
+			return nil
+		}, []EventType{EventUnmark}},
+		{"rename", func(habits *models.HabitList) error {
+			(*habits)[0].Name = "Running"
+			return nil
+		}, []EventType{EventRename}},
+		{"tag", func(habits *models.HabitList) error {
+			(*habits)[0].Tags = []string{"health"}
+			return nil
+		}, []EventType{EventUpdate}},
+		{"reset", func(habits *models.HabitList) error {
+			return (*habits)[0].ResetStreak(time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC), time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC))
+		}, []EventType{EventReset}},
+		{"clear history", func(habits *models.HabitList) error {
+			(*habits)[0].History = nil
+			return (*habits)[0].Recalculate()
+		}, []EventType{EventUnmark}},
+		{"reorder", func(habits *models.HabitList) error {
+			if err := habits.Add(models.Habit{Name: "Reading"}); err != nil {
+				return err
+			}
+			(*habits)[0], (*habits)[1] = (*habits)[1], (*habits)[0]
+			return nil
+		}, []EventType{EventSnapshot}},
+		{"delete", func(habits *models.HabitList) error {
+			return habits.Remove(0)
+		}, []EventType{EventDelete}},
+		{"nothing", func(habits *models.HabitList) error {
+			return nil
+		}, nil},
+	}
+
+	var seen int
+	var want models.HabitList
+	for _, step := range steps {
+		if err := store.Update(func(habits *models.HabitList) error {
+			err := step.change(habits)
+			want = *habits
+			return err
+		}); err != nil {
+			t.Fatalf("%s: Update() error = %v", step.name, err)
+		}
+
+		events, err := store.Events()
+		if err != nil {
+			t.Fatalf("%s: Events() error = %v", step.name, err)
+		}
+		if got := eventTypes(events[seen:]); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: recorded %v, want %v", step.name, got, step.want)
+		}
+		seen = len(events)
+
+		loaded, err := store.Load()
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", step.name, err)
+		}
+		if !jsonEqual(loaded, want) {
+			t.Errorf("%s: Load() = %+v\nwant %+v", step.name, loaded, want)
+		}
+	}
+}
+
+func TestEventStorage_RecordsChangesInPlace(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewEventStorage(filepath.Join(tmpDir, "habits.jsonl"), Options{})
+	store.Save(models.HabitList{
+		{ID: "aaaa0001", Name: "Water", Target: 8, History: []models.Completion{{Date: "2025-01-15", Amount: 3}}},
+		{ID: "aaaa0002", Name: "Run", History: []models.Completion{{Date: "2025-01-13"}, {Date: "2025-01-14"}, {Date: "2025-01-15"}}},
+	})
+
+	// The model methods change completions in place rather than replacing
+	// the history
+	steps := []struct {
+		name   string
+		change func(habits models.HabitList) error
+		want   []EventType
+	}{
+		{"second amount on a day", func(habits models.HabitList) error {
+			return habits[0].AddAmount("2025-01-15", 2, time.Now())
+		}, []EventType{EventMark}},
+		{"note on an existing completion", func(habits models.HabitList) error {
+			return habits[1].AddNote("2025-01-14", "hills")
+		}, []EventType{EventMark}},
+		{"unmark in the middle", func(habits models.HabitList) error {
+			return habits[1].RemoveCompletion("2025-01-14")
+		}, []EventType{EventUnmark}},
+	}
+
+	events, _ := store.Events()
+	seen := len(events)
+	for _, step := range steps {
+		if err := store.Update(func(habits *models.HabitList) error {
+			return step.change(*habits)
+		}); err != nil {
+			t.Fatalf("%s: Update() error = %v", step.name, err)
+		}
+		events, _ := store.Events()
+		if got := eventTypes(events[seen:]); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: recorded %v, want %v", step.name, got, step.want)
+		}
+		seen = len(events)
+	}
+
+	habits, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := habits[0].History[0].Amount; got != 5 {
+		t.Errorf("Water amount = %v, want 5", got)
+	}
+	if got := len(habits[1].History); got != 2 {
+		t.Errorf("Run has %d completions, want 2", got)
+	}
+	if c := habits[1].History; len(c) > 0 && c[0].Date != "2025-01-13" {
+		t.Errorf("Run history = %+v, want 2025-01-13 first", c)
+	}
+}
+
+func TestEventStorage_IgnoresTornWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.jsonl")
+	store := NewEventStorage(path, Options{})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A crash in the middle of an append leaves a partial last line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	file.WriteString(`{"seq":2,"type":"del`)
+	file.Close()
+
+	habits, err := store.Load()
+	if err != nil || len(habits) != 1 {
+		t.Fatalf("Load() = %+v, %v; want the habit from before the crash", habits, err)
+	}
+
+	if err := store.Update(func(habits *models.HabitList) error {
+		return habits.Add(models.Habit{Name: "Reading"})
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	events, err := store.Events()
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if got := eventTypes(events); !reflect.DeepEqual(got, []EventType{EventCreate, EventCreate}) {
+		t.Errorf("Events() = %v, want the partial line replaced", got)
+	}
+}
+
+func TestEventStorage_Compact(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.jsonl")
+	store := NewEventStorage(path, Options{})
+	store.SetCompactEvery(3)
+
+	for _, name := range []string{"Exercise", "Reading", "Meditate", "Water"} {
+		if err := store.Update(func(habits *models.HabitList) error {
+			return habits.Add(models.Habit{Name: name})
+		}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	live, _, err := readEvents(path)
+	if err != nil {
+		t.Fatalf("readEvents() error = %v", err)
+	}
+	if got := eventTypes(live); !reflect.DeepEqual(got, []EventType{EventSnapshot, EventCreate}) {
+		t.Errorf("Log after compaction = %v, want a snapshot and one new event", got)
+	}
+
+	events, err := store.Events()
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if len(events) != 5 {
+		t.Errorf("Events() returned %d events, want 3 archived, the snapshot and 1 new", len(events))
+	}
+
+	habits, err := store.Load()
+	if err != nil || len(habits) != 4 {
+		t.Errorf("Load() = %+v, %v; want 4 habits", habits, err)
+	}
+}
+
+func TestEventStorage_RecordsVacations(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.jsonl")
+	store := NewEventStorage(path, Options{})
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := store.UpdateData(func(data *Data) error {
+		return data.Vacations.Start("2025-08-01", "")
+	}); err != nil {
+		t.Fatalf("UpdateData() error = %v", err)
+	}
+	if err := store.UpdateData(func(data *Data) error {
+		data.Vacations.End(time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC))
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateData() error = %v", err)
+	}
+
+	events, err := store.Events()
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if got := eventTypes(events); !reflect.DeepEqual(got, []EventType{EventCreate, EventVacations, EventVacations}) {
+		t.Errorf("recorded %v, want a create and two vacation events", got)
+	}
+
+	// Snapshots keep them
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	want := models.Vacations{{Start: "2025-08-01", End: "2025-08-09", Reason: models.BreakVacation}}
+	if data, err := store.LoadData(); err != nil || !reflect.DeepEqual(data.Vacations, want) {
+		t.Errorf("LoadData() = %+v, %v; want vacations %+v", data, err, want)
+	}
+}
+
+func TestEventStorage_RefusesUnknownEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.jsonl")
+	if err := os.WriteFile(path, []byte(
+		`{"seq":1,"type":"create","habit_id":"a1b2c3d4","habit":{"id":"a1b2c3d4","name":"Exercise"}}`+"\n"+
+			`{"seq":2,"type":"teleport","habit_id":"a1b2c3d4"}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	if _, err := NewEventStorage(path, Options{}).Load(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Load() error = %v, want ErrNewerSchema", err)
+	}
+}
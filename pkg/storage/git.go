@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// gitLockSuffix names the lock that keeps a save and its commit together.
+const gitLockSuffix = ".git.lock"
+
+// gitIgnore is written to repositories GitStorage creates, so only the data
+// file is ever committed there.
+const gitIgnore = "*.lock\n*.bak\n*.tmp\n"
+
+// ErrNoGit is returned when the git executable cannot be found.
+var ErrNoGit = errors.New("git is not installed or not in PATH")
+
+// errUnchanged stops an update that changed nothing from writing the file,
+// which would only refresh its metadata and make an empty commit.
+var errUnchanged = errors.New("habits unchanged")
+
+// Commit is one version of the data file in git.
+type Commit struct {
+	Hash    string
+	Short   string
+	Time    time.Time
+	Subject string
+}
+
+// GitStorage keeps habits in a JSON file and commits the file to a local git
+// repository after every change, with a message describing it such as
+// "mark: Morning Exercise (streak 12)". If the file is not inside a
+// repository, one is created in its directory. Git is run directly, without
+// a shell, and never contacts a remote.
+type GitStorage struct {
+	*JSONStorage
+	dir  string // Directory of the data file, where git runs
+	base string // Name of the data file in dir
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendGit,
+		Description: "JSON file committed to git",
+		Extension:   ".json",
+		Open:        func(location string, opts Options) (Storage, error) { return NewGitStorage(location, opts), nil },
+	})
+}
+
+// NewGitStorage creates a new git-backed storage instance.
+func NewGitStorage(filePath string, opts Options) *GitStorage {
+	s := &GitStorage{
+		JSONStorage: NewJSONStorage(filePath, opts),
+		dir:         filepath.Dir(filePath),
+		base:        filepath.Base(filePath),
+	}
+	s.outer = s
+	return s
+}
+
+// Save replaces the habits and commits the data file.
+func (s *GitStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData writes the habits and vacations and commits the data file.
+func (s *GitStorage) SaveData(data Data) error {
+	return s.UpdateData(func(current *Data) error {
+		*current = data
+		return nil
+	})
+}
+
+// Update changes the habits like UpdateData.
+func (s *GitStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData changes the habits and vacations like JSONStorage.UpdateData
+// and commits the result. If nothing changed and the file has the current
+// schema version, nothing is written or committed.
+func (s *GitStorage) UpdateData(fn func(*Data) error) error {
+	var before, after Data
+	var from int
+	return s.commitAfter(func() error {
+		return s.JSONStorage.UpdateData(func(data *Data) error {
+			before, from = cloneData(*data), s.version
+			if err := fn(data); err != nil {
+				return err
+			}
+			after = cloneData(*data)
+			if s.Exists() && from == SchemaVersion && jsonEqual(before, after) {
+				return errUnchanged
+			}
+			return nil
+		})
+	}, func() string {
+		if from < SchemaVersion && jsonEqual(before, after) {
+			return fmt.Sprintf("upgrade data file from schema version %d to %d", from, SchemaVersion)
+		}
+		return describeChanges(before, after)
+	})
+}
+
+// History returns the commits of the data file, newest first, at most limit
+// of them if limit is positive.
+func (s *GitStorage) History(limit int) ([]Commit, error) {
+	if _, err := s.git("rev-parse", "--git-dir"); err != nil {
+		if errors.Is(err, ErrNoGit) {
+			return nil, err
+		}
+		return nil, nil // Not committed yet
+	}
+
+	args := []string{"log", "--format=%H%x00%h%x00%aI%x00%s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	out, err := s.git(append(args, "--", s.base)...)
+	if err != nil {
+		// A repository without commits has no history yet
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit date '%s': %w", fields[2], err)
+		}
+		commits = append(commits, Commit{Hash: fields[0], Short: fields[1], Time: at, Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// Revert restores the habits as they were after a commit and commits that as
+// a new version, so the history is kept.
+func (s *GitStorage) Revert(rev string) (Commit, error) {
+	if strings.HasPrefix(rev, "-") {
+		return Commit{}, fmt.Errorf("invalid commit '%s'", rev)
+	}
+	out, err := s.git("log", "-1", "--format=%H%x00%h%x00%aI%x00%s", rev, "--")
+	if err != nil {
+		return Commit{}, fmt.Errorf("unknown commit '%s': %w", rev, err)
+	}
+	fields := strings.SplitN(strings.TrimSpace(out), "\x00", 4)
+	if len(fields) != 4 {
+		return Commit{}, fmt.Errorf("unknown commit '%s'", rev)
+	}
+	commit := Commit{Hash: fields[0], Short: fields[1], Subject: fields[3]}
+	commit.Time, _ = time.Parse(time.RFC3339, fields[2])
+
+	data, err := s.git("show", commit.Hash+":./"+s.base)
+	if err != nil {
+		return Commit{}, fmt.Errorf("commit %s has no %s: %w", commit.Short, s.base, err)
+	}
+	restored, err := Decode([]byte(data))
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to read habits of commit %s: %w", commit.Short, err)
+	}
+
+	err = s.commitAfter(func() error {
+		return s.JSONStorage.SaveData(restored)
+	}, func() string {
+		return fmt.Sprintf("revert: to %s (%s)", commit.Short, commit.Subject)
+	})
+	return commit, err
+}
+
+// commitAfter runs change and then commits the data file with the message
+// returned by message, holding a lock so no other process saves in between.
+func (s *GitStorage) commitAfter(change func() error, message func() string) error {
+	if err := s.ensureDir(); err != nil {
+		return err
+	}
+	lock, err := acquireLock(s.filePath+gitLockSuffix, true, s.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if err := change(); errors.Is(err, errUnchanged) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := s.commit(message()); err != nil {
+		return fmt.Errorf("habits were saved, but committing them to git failed: %w", err)
+	}
+	return nil
+}
+
+// commit commits the data file if it changed, creating the repository first
+// if the file is not in one.
+func (s *GitStorage) commit(message string) error {
+	if _, err := s.git("rev-parse", "--git-dir"); err != nil {
+		if errors.Is(err, ErrNoGit) {
+			return err
+		}
+		if _, err := s.git("init", "--quiet"); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(s.dir, ".gitignore"), []byte(gitIgnore), 0644); err != nil {
+			return fmt.Errorf("failed to write .gitignore: %w", err)
+		}
+		if err := s.commitFile(".gitignore", "Ignore lock and backup files of habit-tracker"); err != nil {
+			return err
+		}
+	}
+
+	status, err := s.git("status", "--porcelain", "--", s.base)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+	return s.commitFile(s.base, message)
+}
+
+// commitFile commits one file in the data file's directory, leaving anything
+// else staged in the repository alone.
+func (s *GitStorage) commitFile(name, message string) error {
+	if _, err := s.git("add", "--", name); err != nil {
+		return err
+	}
+	args := []string{"commit", "--quiet", "--no-verify", "-m", message, "--", name}
+	if email, _ := s.git("config", "user.email"); strings.TrimSpace(email) == "" {
+		// Commits need an author even where git has not been set up
+		args = append([]string{"-c", "user.name=habit-tracker", "-c", "user.email=habit-tracker@localhost"}, args...)
+	}
+	_, err := s.git(args...)
+	return err
+}
+
+// git runs a git command in the data file's directory and returns its output.
+func (s *GitStorage) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", s.dir}, args...)...)
+	// Never prompt for anything
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", ErrNoGit
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git: %s", msg)
+		}
+		return "", fmt.Errorf("git: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// describeChanges summarizes the changes from before to after as a commit
+// message: the first change as the subject, and all of them in the body if
+// there are several.
+func describeChanges(before, after Data) string {
+	var lines []string
+	for _, event := range diff(before, after, 0, time.Time{}) {
+		lines = append(lines, describeEvent(event, before, after))
+	}
+
+	switch len(lines) {
+	case 0:
+		return "update habits"
+	case 1:
+		return lines[0]
+	default:
+		return fmt.Sprintf("%s (and %d more)\n\n%s", lines[0], len(lines)-1, strings.Join(lines, "\n"))
+	}
+}
+
+// describeEvent describes one change, e.g. "mark: Morning Exercise (streak 12)".
+func describeEvent(event Event, before, after Data) string {
+	switch event.Type {
+	case EventCreate:
+		return "add: " + event.Name
+	case EventDelete:
+		return "delete: " + event.Name
+	case EventRename:
+		if old, _ := before.Habits.FindByID(event.HabitID); old != nil {
+			return fmt.Sprintf("rename: %s -> %s", old.Name, event.Name)
+		}
+		return "rename: " + event.Name
+	case EventMark:
+		if habit, _ := after.Habits.FindByID(event.HabitID); habit != nil {
+			return fmt.Sprintf("mark: %s (streak %d)", event.Name, habit.Streak)
+		}
+		return "mark: " + event.Name
+	case EventUnmark:
+		return fmt.Sprintf("unmark: %s %s", event.Name, event.Date)
+	case EventReset:
+		return "reset: " + event.Name
+	case EventUpdate:
+		return "edit: " + event.Name
+	case EventVacations:
+		return "vacation: " + describeVacations(before.Vacations, after.Vacations)
+	default:
+		return fmt.Sprintf("update: %d habit(s)", len(after.Habits))
+	}
+}
+
+// describeVacations summarizes how the vacations changed, e.g. "from
+// 2025-08-01 through 2025-08-15".
+func describeVacations(before, after models.Vacations) string {
+	var changes []string
+	for _, b := range after {
+		if !slices.Contains(before, b) {
+			if b.End == "" {
+				changes = append(changes, "from "+b.Start)
+			} else {
+				changes = append(changes, fmt.Sprintf("from %s through %s", b.Start, b.End))
+			}
+		}
+	}
+	for _, b := range before {
+		if !slices.ContainsFunc(after, func(a models.Break) bool { return a.Start == b.Start }) {
+			changes = append(changes, "cancelled the one from "+b.Start)
+		}
+	}
+	if len(changes) == 0 {
+		return "updated"
+	}
+	return strings.Join(changes, ", ")
+}
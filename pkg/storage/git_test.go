@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// setupGit skips the test without git and keeps the user's git configuration
+// out of it.
+func setupGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func subjects(t *testing.T, store *GitStorage) []string {
+	t.Helper()
+	commits, err := store.History(0)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	var list []string
+	for _, c := range commits {
+		list = append(list, c.Subject)
+	}
+	return list
+}
+
+func TestGitStorage_CommitsChanges(t *testing.T) {
+	setupGit(t)
+	store := NewGitStorage(filepath.Join(t.TempDir(), "habits", "habits.json"), Options{})
+
+	if commits, err := store.History(0); err != nil || len(commits) != 0 {
+		t.Fatalf("History() before saving = %v, %v; want none", commits, err)
+	}
+
+	steps := []func(habits *models.HabitList) error{
+		func(habits *models.HabitList) error { return habits.Add(models.Habit{Name: "Morning Exercise"}) },
+		func(habits *models.HabitList) error {
+			(*habits)[0].History = []models.Completion{{Date: "2025-01-15"}}
+			(*habits)[0].Streak = 12
+			return nil
+		},
+		func(habits *models.HabitList) error {
+			(*habits)[0].Name = "Workout"
+			return nil
+		},
+		func(habits *models.HabitList) error { return nil },
+	}
+	for _, step := range steps {
+		if err := store.Update(step); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	want := []string{
+		"rename: Morning Exercise -> Workout",
+		"mark: Morning Exercise (streak 12)",
+		"add: Morning Exercise",
+	}
+	if got := subjects(t, store); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+
+	// Reverting to the mark brings the old name back as a new commit
+	commits, _ := store.History(0)
+	if _, err := store.Revert(commits[1].Short); err != nil {
+		t.Fatalf("Revert() error = %v", err)
+	}
+	habits, err := store.Load()
+	if err != nil || len(habits) != 1 || habits[0].Name != "Morning Exercise" || habits[0].Streak != 12 {
+		t.Errorf("Load() after Revert() = %+v, %v", habits, err)
+	}
+	if got := subjects(t, store); len(got) != 4 || !strings.HasPrefix(got[0], "revert: to "+commits[1].Short) {
+		t.Errorf("History() after Revert() = %q", got)
+	}
+
+	if _, err := store.Revert("--help"); err == nil {
+		t.Error("Expected an option to be refused as a commit")
+	}
+}
+
+func TestGitStorage_LeavesOtherFilesAlone(t *testing.T) {
+	setupGit(t)
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	// A dotfiles repository with a change of its own staged
+	git("init", "--quiet")
+	os.WriteFile(filepath.Join(dir, ".bashrc"), []byte("alias h=habit\n"), 0644)
+	git("add", ".bashrc")
+
+	store := NewGitStorage(filepath.Join(dir, "habits.json"), Options{})
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if status := git("status", "--porcelain"); !strings.Contains(status, "A  .bashrc") {
+		t.Errorf("Expected .bashrc to stay staged, got status:\n%s", status)
+	}
+	if files := git("show", "--name-only", "--format=", "HEAD"); strings.TrimSpace(files) != "habits.json" {
+		t.Errorf("Expected only habits.json to be committed, got %q", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitignore")); !os.IsNotExist(err) {
+		t.Error("Expected no .gitignore to be added to an existing repository")
+	}
+}
+
+func TestGitStorage_CommitsUpgrade(t *testing.T) {
+	setupGit(t)
+	testFile := filepath.Join(t.TempDir(), "habits", "habits.json")
+	store := NewGitStorage(testFile, Options{})
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// An older version's file, as left by restoring one by hand
+	if err := os.WriteFile(testFile, []byte(`[{"name": "Exercise", "last_done": "2025-01-15", "streak": 2}]`), 0600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+	if _, err := store.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{fmt.Sprintf("upgrade data file from schema version 1 to %d", SchemaVersion), "add: Exercise"}
+	if got := subjects(t, store); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	before := models.HabitList{{ID: "a1b2c3d4", Name: "Exercise"}, {ID: "0000ffff", Name: "Reading"}}
+	after := models.HabitList{{ID: "a1b2c3d4", Name: "Exercise", Streak: 1, History: []models.Completion{{Date: "2025-01-15"}}}}
+
+	want := "delete: Reading (and 1 more)\n\ndelete: Reading\nmark: Exercise (streak 1)"
+	if got := describeChanges(Data{Habits: before}, Data{Habits: after}); got != want {
+		t.Errorf("describeChanges() = %q, want %q", got, want)
+	}
+
+	vacation := models.Break{Start: "2025-08-01", End: "2025-08-15", Reason: models.BreakVacation}
+	want = "vacation: from 2025-08-01 through 2025-08-15"
+	if got := describeChanges(Data{Habits: after}, Data{Habits: after, Vacations: models.Vacations{vacation}}); got != want {
+		t.Errorf("describeChanges() = %q, want %q", got, want)
+	}
+}
@@ -2,82 +2,260 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
 )
 
-// JSONStorage implements habit storage using JSON files.
+// JSONStorage implements habit storage using JSON files. Concurrent habit
+// processes are serialized with an advisory lock on <file>.lock: shared while
+// loading, exclusive while saving or updating.
 type JSONStorage struct {
-	filePath string
+	filePath    string
+	lockTimeout time.Duration
+	clock       models.Clock
+	metadata    Metadata // Metadata of the file as last loaded
+	version     int      // Schema version of the file as last loaded
+	outer       Storage  // Store that upgrades older files, such as a GitStorage wrapping this one
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendFile,
+		Aliases:     []string{BackendJSON},
+		Description: "JSON file",
+		Extension:   ".json",
+		Open:        func(location string, opts Options) (Storage, error) { return NewJSONStorage(location, opts), nil },
+	})
 }
 
 // NewJSONStorage creates a new JSON storage instance.
-func NewJSONStorage(filePath string) *JSONStorage {
-	return &JSONStorage{
-		filePath: filePath,
+func NewJSONStorage(filePath string, opts Options) *JSONStorage {
+	s := &JSONStorage{
+		filePath:    filePath,
+		lockTimeout: opts.lockTimeout(),
+		clock:       opts.clock(),
 	}
+	s.outer = s
+	return s
+}
+
+// SetLockTimeout sets how long to wait for another process to release the
+// data file.
+func (s *JSONStorage) SetLockTimeout(timeout time.Duration) {
+	s.lockTimeout = timeout
 }
 
-// Load reads habits from the JSON file.
+// Load reads habits from the JSON file, following its vacations.
 func (s *JSONStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData reads the habits and vacations from the JSON file. Files written
+// with an older schema version are upgraded in place, after copying the
+// original to <file>.v<version>.bak. Files from a newer version are refused.
+func (s *JSONStorage) LoadData() (Data, error) {
 	// Check if file exists
 	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
 		// Return empty list if file doesn't exist
-		return models.HabitList{}, nil
+		return Data{Habits: models.HabitList{}}, nil
 	}
 
-	// Read file
-	data, err := os.ReadFile(s.filePath)
+	var data Data
+	var version int
+	err := s.withLock(false, func() error {
+		var err error
+		data, version, err = s.read()
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return Data{}, err
 	}
 
-	// Handle empty file
-	if len(data) == 0 {
-		return models.HabitList{}, nil
+	// Upgrading rewrites the file, which needs the exclusive lock, and goes
+	// through the outer store so that, for example, git records it
+	if version < SchemaVersion {
+		err := s.outer.UpdateData(func(d *Data) error {
+			data = *d
+			return nil
+		})
+		if err != nil {
+			return Data{}, err
+		}
 	}
 
-	// Unmarshal JSON
-	var habits models.HabitList
-	if err := json.Unmarshal(data, &habits); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	return data, nil
+}
+
+// Save replaces the habits in the JSON file, keeping its vacations.
+func (s *JSONStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData writes the habits and vacations to the JSON file atomically,
+// keeping the previous version as <file>.bak.
+func (s *JSONStorage) SaveData(data Data) error {
+	if err := s.ensureDir(); err != nil {
+		return err
 	}
+	return s.withLock(true, func() error {
+		return s.write(data)
+	})
+}
 
-	return habits, nil
+// Update passes the habits, following the vacations, to fn and saves the
+// result like UpdateData.
+func (s *JSONStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
 }
 
-// Save writes habits to the JSON file.
-func (s *JSONStorage) Save(habits models.HabitList) error {
-	// Ensure directory exists
-	dir := filepath.Dir(s.filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// UpdateData loads the habits and vacations, passes them to fn and saves the
+// result, holding the exclusive lock throughout so no other process can
+// change the file in between. Nothing is saved if fn returns an error, which
+// UpdateData returns unchanged.
+func (s *JSONStorage) UpdateData(fn func(*Data) error) error {
+	if err := s.ensureDir(); err != nil {
+		return err
+	}
+	return s.withLock(true, func() error {
+		data, version, err := s.read()
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+		if version < SchemaVersion {
+			if err := s.backUpOriginal(version); err != nil {
+				return err
+			}
+		}
+		return s.write(data)
+	})
+}
+
+// withLock runs fn while holding the lock on the data file.
+func (s *JSONStorage) withLock(exclusive bool, fn func() error) error {
+	lock, err := acquireLock(s.filePath+lockSuffix, exclusive, s.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	return fn()
+}
+
+// ensureDir creates the directory holding the data file.
+func (s *JSONStorage) ensureDir() error {
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
+	return nil
+}
+
+// read parses the data file, returning the schema version it was written
+// with. The caller must hold the lock.
+func (s *JSONStorage) read() (Data, int, error) {
+	content, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		s.version = SchemaVersion
+		return Data{Habits: models.HabitList{}}, SchemaVersion, nil
+	}
+	if err != nil {
+		return Data{}, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	data, metadata, version, err := decode(content)
+	if err != nil {
+		return Data{}, 0, err
+	}
+	s.metadata, s.version = metadata, version
+
+	return data, version, nil
+}
+
+// backUpOriginal copies a data file written with an older schema version to
+// <file>.v<version>.bak, with the same permissions, before it is first
+// rewritten in the current one. The caller must hold the exclusive lock.
+func (s *JSONStorage) backUpOriginal(version int) error {
+	backupPath := fmt.Sprintf("%s.v%d.bak", s.filePath, version)
+	if _, err := os.Stat(backupPath); !os.IsNotExist(err) {
+		return nil
+	}
+	if err := copyFile(s.filePath, backupPath); err != nil {
+		return fmt.Errorf("failed to back up data file before migration: %w", err)
+	}
+	return nil
+}
+
+// write saves data to the data file. The caller must hold the exclusive lock.
+func (s *JSONStorage) write(data Data) error {
+	now := s.clock.Now()
+	if s.metadata.CreatedAt.IsZero() {
+		s.metadata.CreatedAt = now
+	}
+	s.metadata.UpdatedAt = now
 
 	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(habits, "", "  ")
+	content, err := encode(data, s.metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(s.filePath, data, 0644); err != nil {
+	// Write to file without ever leaving it half-written
+	if err := writeFileAtomic(s.filePath, content, 0600); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
 	return nil
 }
 
-// Delete removes the storage file.
+// Delete removes the storage file and the old versions kept beside it.
 func (s *JSONStorage) Delete() error {
-	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
-		return nil // File doesn't exist, nothing to delete
+	if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.removeBackups()
+}
+
+// purgeHistory removes the previous version kept in <file>.bak and the
+// copies kept from before migrations in <file>.v<version>.bak.
+func (s *JSONStorage) purgeHistory() error {
+	return s.withLock(true, s.removeBackups)
+}
+
+// removeBackups removes <file>.bak and every <file>.v<version>.bak.
+func (s *JSONStorage) removeBackups() error {
+	paths := []string{s.filePath + backupSuffix}
+	dir, base := filepath.Split(s.filePath)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
-	return os.Remove(s.filePath)
+	for _, entry := range entries {
+		version, ok := strings.CutPrefix(entry.Name(), base+".v")
+		if !ok {
+			continue
+		}
+		if version, ok = strings.CutSuffix(version, backupSuffix); !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(version); err == nil {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 // Exists checks if the storage file exists.
@@ -91,11 +269,66 @@ func (s *JSONStorage) GetPath() string {
 	return s.filePath
 }
 
-// Storage defines the interface for habit persistence.
+// Data is everything a store keeps: the habits and the vacations that apply
+// to all of them.
+type Data struct {
+	Habits    models.HabitList
+	Vacations models.Vacations
+}
+
+// Storage defines the interface for habit persistence. Load, Save and Update
+// work on the habits alone: loaded habits follow the stored vacations, and
+// saving keeps them. LoadData, SaveData and UpdateData include the vacations.
 type Storage interface {
 	Load() (models.HabitList, error)
 	Save(models.HabitList) error
+	Update(func(*models.HabitList) error) error
+	LoadData() (Data, error)
+	SaveData(Data) error
+	UpdateData(func(*Data) error) error
 	Delete() error
 	Exists() bool
 	GetPath() string
 }
+
+// loadHabits implements Load with store's LoadData.
+func loadHabits(store Storage) (models.HabitList, error) {
+	data, err := store.LoadData()
+	if err != nil {
+		return nil, err
+	}
+	data.Habits.SetVacations(data.Vacations)
+	return data.Habits, nil
+}
+
+// saveHabits implements Save with store's UpdateData.
+func saveHabits(store Storage, habits models.HabitList) error {
+	return store.UpdateData(func(data *Data) error {
+		data.Habits = habits
+		return nil
+	})
+}
+
+// updateHabits implements Update with store's UpdateData.
+func updateHabits(store Storage, fn func(*models.HabitList) error) error {
+	return store.UpdateData(func(data *Data) error {
+		data.Habits.SetVacations(data.Vacations)
+		return fn(&data.Habits)
+	})
+}
+
+// cloneData deep-copies data, with an empty habit list for nil.
+func cloneData(data Data) Data {
+	return Data{Habits: cloneHabits(data.Habits), Vacations: slices.Clone(data.Vacations)}
+}
+
+// MergeData combines two versions of the data changed from a common base, the
+// habits with models.Merge and the vacations with models.MergeVacations.
+func MergeData(base, ours, theirs Data) (Data, []models.MergeConflict, error) {
+	habits, conflicts, err := models.Merge(base.Habits, ours.Habits, theirs.Habits)
+	if err != nil {
+		return Data{}, nil, err
+	}
+	vacations := models.MergeVacations(base.Vacations, ours.Vacations, theirs.Vacations)
+	return Data{Habits: habits, Vacations: vacations}, conflicts, nil
+}
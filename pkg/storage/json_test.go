@@ -13,7 +13,7 @@ func TestJSONStorage_SaveAndLoad(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "test_habits.json")
 
-	store := NewJSONStorage(testFile)
+	store := NewJSONStorage(testFile, Options{})
 
 	// Create test data
 	habits := models.HabitList{
@@ -60,7 +60,7 @@ func TestJSONStorage_LoadNonExistentFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "nonexistent.json")
 
-	store := NewJSONStorage(testFile)
+	store := NewJSONStorage(testFile, Options{})
 
 	// Load from non-existent file should return empty list
 	habits, err := store.Load()
@@ -83,7 +83,7 @@ func TestJSONStorage_LoadEmptyFile(t *testing.T) {
 		t.Fatalf("Failed to create empty file: %v", err)
 	}
 
-	store := NewJSONStorage(testFile)
+	store := NewJSONStorage(testFile, Options{})
 
 	// Load from empty file should return empty list
 	habits, err := store.Load()
@@ -100,7 +100,7 @@ func TestJSONStorage_Delete(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "delete_test.json")
 
-	store := NewJSONStorage(testFile)
+	store := NewJSONStorage(testFile, Options{})
 
 	// Create file with data
 	habits := models.HabitList{
@@ -115,6 +115,7 @@ func TestJSONStorage_Delete(t *testing.T) {
 	if !store.Exists() {
 		t.Fatal("Expected file to exist before Delete()")
 	}
+	os.WriteFile(testFile+".v1.bak", []byte("[]"), 0600)
 
 	// Delete file
 	err = store.Delete()
@@ -126,11 +127,14 @@ func TestJSONStorage_Delete(t *testing.T) {
 	if store.Exists() {
 		t.Error("Expected file not to exist after Delete()")
 	}
+	if _, err := os.Stat(testFile + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("Expected the pre-migration backup to be deleted too")
+	}
 }
 
 func TestJSONStorage_GetPath(t *testing.T) {
 	path := "/tmp/test.json"
-	store := NewJSONStorage(path)
+	store := NewJSONStorage(path, Options{})
 
 	if store.GetPath() != path {
 		t.Errorf("GetPath() = %v, want %v", store.GetPath(), path)
@@ -141,7 +145,7 @@ func TestJSONStorage_SaveCreatesDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "subdir", "habits.json")
 
-	store := NewJSONStorage(testFile)
+	store := NewJSONStorage(testFile, Options{})
 
 	// Save should create the directory
 	habits := models.HabitList{
@@ -157,3 +161,31 @@ func TestJSONStorage_SaveCreatesDirectory(t *testing.T) {
 		t.Error("Expected file to exist after Save()")
 	}
 }
+
+func TestJSONStorage_LoadMigratesLegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "legacy.json")
+
+	// File written before completion history was tracked
+	legacy := `[{"name": "Exercise", "last_done": "2025-01-15", "streak": 2}]`
+	if err := os.WriteFile(testFile, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to create legacy file: %v", err)
+	}
+
+	store := NewJSONStorage(testFile, Options{})
+
+	habits, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(habits) != 1 {
+		t.Fatalf("Load() length = %v, want 1", len(habits))
+	}
+	if len(habits[0].History) != 2 {
+		t.Errorf("Load() history length = %v, want 2", len(habits[0].History))
+	}
+	if habits[0].Streak != 2 || habits[0].LastDone != "2025-01-15" {
+		t.Errorf("Load() streak = %v, lastDone = %v, want 2, 2025-01-15", habits[0].Streak, habits[0].LastDone)
+	}
+}
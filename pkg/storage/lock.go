@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLockTimeout is how long JSONStorage waits for another process to
+// release the data file before giving up.
+const DefaultLockTimeout = 5 * time.Second
+
+// lockSuffix is appended to a data file's path to name its lock file. The lock
+// is taken on a separate file because Save replaces the data file itself.
+const lockSuffix = ".lock"
+
+// lockRetryInterval is how often a busy lock is retried.
+const lockRetryInterval = 25 * time.Millisecond
+
+// ErrLocked is returned when the data file stays locked by another process
+// for longer than the lock timeout.
+var ErrLocked = errors.New("data file is locked by another habit process")
+
+// errBusy is returned by tryLock when the lock is held elsewhere.
+var errBusy = errors.New("lock busy")
+
+// acquireLock takes the lock file at path, shared or exclusive, retrying until
+// timeout has passed.
+func acquireLock(path string, exclusive bool, timeout time.Duration) (*fileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := tryLock(path, exclusive)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, errBusy) {
+			return nil, fmt.Errorf("failed to lock data file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			if pid := lockHolder(path); pid != 0 {
+				return nil, fmt.Errorf("%w (process %d held %s for longer than the %s timeout)", ErrLocked, pid, path, timeout)
+			}
+			return nil, fmt.Errorf("%w (%s was held for longer than the %s timeout)", ErrLocked, path, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// lockHolder returns the process ID written into the lock file at path by the
+// process holding it exclusively, or 0 if it is not known.
+func lockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
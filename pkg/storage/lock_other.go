@@ -0,0 +1,41 @@
+//go:build !unix
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file must be before it is assumed to be left
+// over from a crashed process.
+const staleLockAge = 10 * time.Minute
+
+// fileLock is a lock file created with O_EXCL, used where flock(2) is not
+// available. Shared and exclusive locks are the same.
+type fileLock struct {
+	path string
+}
+
+// tryLock creates the lock file at path, failing with errBusy if it exists.
+func tryLock(path string, exclusive bool) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+		}
+		return nil, errBusy
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	file.Close()
+	return &fileLock{path: path}, nil
+}
+
+// release removes the lock file.
+func (l *fileLock) release() error {
+	return os.Remove(l.path)
+}
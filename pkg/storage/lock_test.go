@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func TestJSONStorage_LockTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+	store := NewJSONStorage(testFile, Options{})
+	store.SetLockTimeout(50 * time.Millisecond)
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	lock, err := acquireLock(testFile+lockSuffix, true, time.Second)
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+
+	_, err = store.Load()
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("Load() error = %v, want ErrLocked", err)
+	}
+	if want := fmt.Sprintf("process %d", os.Getpid()); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Load() error = %v, want it to name %s", err, want)
+	}
+	if err := store.Save(models.HabitList{}); !errors.Is(err, ErrLocked) {
+		t.Errorf("Save() error = %v, want ErrLocked", err)
+	}
+
+	lock.release()
+	if _, err := store.Load(); err != nil {
+		t.Errorf("Load() after release error = %v", err)
+	}
+}
+
+func TestJSONStorage_UpdateConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each worker uses its own storage, like a separate habit process
+			store := NewJSONStorage(testFile, Options{})
+			errs <- store.Update(func(habits *models.HabitList) error {
+				return habits.Add(models.Habit{Name: fmt.Sprintf("Habit %d", i)})
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	habits, err := NewJSONStorage(testFile, Options{}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(habits) != workers {
+		t.Errorf("Got %d habit(s) after concurrent updates, want %d", len(habits), workers)
+	}
+}
+
+func TestJSONStorage_UpdateError(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+	store := NewJSONStorage(testFile, Options{})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	errStop := errors.New("stop")
+	err := store.Update(func(habits *models.HabitList) error {
+		*habits = nil
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Update() error = %v, want the callback's error", err)
+	}
+
+	habits, _ := store.Load()
+	if len(habits) != 1 {
+		t.Errorf("Expected nothing to be saved when the callback fails, got %d habit(s)", len(habits))
+	}
+}
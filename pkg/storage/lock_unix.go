@@ -0,0 +1,52 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory flock(2) lock on a lock file.
+type fileLock struct {
+	file      *os.File
+	exclusive bool
+}
+
+// tryLock takes a shared or exclusive flock on path without blocking. An
+// exclusive holder writes its process ID into the file so that a process
+// left waiting can say who holds it.
+func tryLock(path string, exclusive bool) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errBusy
+		}
+		return nil, err
+	}
+	if exclusive && file.Truncate(0) == nil {
+		fmt.Fprintf(file, "%d\n", os.Getpid())
+	}
+	return &fileLock{file: file, exclusive: exclusive}, nil
+}
+
+// release drops the lock. The lock file is left in place, since removing it
+// could let two processes lock different files of the same name.
+func (l *fileLock) release() error {
+	defer l.file.Close()
+	if l.exclusive {
+		l.file.Truncate(0)
+	}
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// MemoryStorage keeps habits in memory only, for tests and trying commands
+// out; everything is lost when the process exits.
+type MemoryStorage struct {
+	mu        sync.Mutex
+	name      string
+	habits    models.HabitList // nil until the first save
+	vacations models.Vacations
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendMemory,
+		Description: "in memory, not saved",
+		Open:        func(location string, _ Options) (Storage, error) { return NewMemoryStorage(location), nil },
+	})
+}
+
+// NewMemoryStorage creates an empty in-memory storage. The name is only used
+// as its path.
+func NewMemoryStorage(name string) *MemoryStorage {
+	return &MemoryStorage{name: name}
+}
+
+// Load returns a copy of the stored habits, following the vacations.
+func (s *MemoryStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData returns a copy of the stored habits and vacations.
+func (s *MemoryStorage) LoadData() (Data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneData(Data{Habits: s.habits, Vacations: s.vacations}), nil
+}
+
+// Save replaces the stored habits with a copy of the given ones.
+func (s *MemoryStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData replaces the stored habits and vacations with a copy of the given
+// ones.
+func (s *MemoryStorage) SaveData(data Data) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(data)
+	return nil
+}
+
+// Update changes the habits like UpdateData.
+func (s *MemoryStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData passes a copy of the habits and vacations to fn and stores the
+// result unless fn returns an error, which UpdateData returns unchanged.
+func (s *MemoryStorage) UpdateData(fn func(*Data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := cloneData(Data{Habits: s.habits, Vacations: s.vacations})
+	if err := fn(&data); err != nil {
+		return err
+	}
+	s.store(data)
+	return nil
+}
+
+// Delete removes all habits and vacations.
+func (s *MemoryStorage) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.habits, s.vacations = nil, nil
+	return nil
+}
+
+// Exists reports whether habits have been saved.
+func (s *MemoryStorage) Exists() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.habits != nil
+}
+
+// GetPath returns the storage's name.
+func (s *MemoryStorage) GetPath() string {
+	return s.name
+}
+
+// store keeps a copy of data. The caller must hold s.mu.
+func (s *MemoryStorage) store(data Data) {
+	data = cloneData(data)
+	s.habits, s.vacations = data.Habits, data.Vacations
+	s.habits.EnsureIDs()
+}
+
+// cloneHabits deep-copies a habit list, returning an empty list for nil.
+func cloneHabits(habits models.HabitList) models.HabitList {
+	clone := make(models.HabitList, len(habits))
+	for i, h := range habits {
+		clone[i] = cloneHabit(h)
+	}
+	return clone
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// Names of the built-in backends, which are also their URL schemes.
+const (
+	BackendFile    = "file"
+	BackendSQLite  = "sqlite"
+	BackendEvents  = "events"
+	BackendMemory  = "mem"
+	BackendGit     = "git"
+	BackendWebDAV  = "webdav"
+	BackendWebDAVS = "webdavs"
+)
+
+// BackendJSON is another name for the file backend.
+const BackendJSON = "json"
+
+// Options configures a storage when it is created. The zero value uses the
+// system clock and DefaultLockTimeout.
+type Options struct {
+	Clock       models.Clock  // Supplies the time recorded in metadata and events
+	LockTimeout time.Duration // How long to wait for another process; zero for DefaultLockTimeout
+}
+
+// clock returns the clock to record times with.
+func (o Options) clock() models.Clock {
+	if o.Clock == nil {
+		return models.SystemClock{}
+	}
+	return o.Clock
+}
+
+// lockTimeout returns how long to wait for another process.
+func (o Options) lockTimeout() time.Duration {
+	if o.LockTimeout <= 0 {
+		return DefaultLockTimeout
+	}
+	return o.LockTimeout
+}
+
+// Backend describes a kind of storage that data URLs can select by scheme.
+type Backend struct {
+	Name        string                                               // URL scheme, e.g. "sqlite"
+	Aliases     []string                                             // Other names accepted for the backend
+	Description string                                               // Short description for help and errors
+	Extension   string                                               // Extension of its data files, e.g. ".db"; empty if it has none
+	Open        func(location string, opts Options) (Storage, error) // Creates the storage for the part of a URL after "scheme://"
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Backend) // By name and alias
+	backends   []*Backend                  // In name order
+)
+
+// Register makes a backend available to Open and New under its name and
+// aliases. Backends register themselves in an init function; registering a
+// name twice panics.
+func Register(backend Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if backend.Name == "" || backend.Open == nil {
+		panic("storage: Register needs a backend name and Open function")
+	}
+	b := &backend
+	for _, name := range append([]string{b.Name}, b.Aliases...) {
+		name = strings.ToLower(name)
+		if _, dup := registry[name]; dup {
+			panic("storage: backend " + name + " registered twice")
+		}
+		registry[name] = b
+	}
+	backends = append(backends, b)
+	sort.Slice(backends, func(i, j int) bool { return backends[i].Name < backends[j].Name })
+}
+
+// Lookup returns the backend registered under a name or alias.
+func Lookup(name string) (*Backend, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if b, ok := registry[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown storage backend '%s' (expected %s)", name, strings.Join(backendNames(), ", "))
+}
+
+// Backends returns all registered backends, ordered by name.
+func Backends() []Backend {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]Backend, len(backends))
+	for i, b := range backends {
+		list[i] = *b
+	}
+	return list
+}
+
+// backendNames lists the names of the registered backends. The caller must
+// hold registryMu.
+func backendNames() []string {
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// ParseURL splits a data URL such as "sqlite://~/habits.db" into the backend
+// name and the location that follows "://". A leading "~/" in the location
+// stands for the home directory. Anything without a scheme is the path of a
+// JSON data file.
+func ParseURL(dataURL string) (backend, location string) {
+	backend, location, ok := strings.Cut(dataURL, "://")
+	if !ok {
+		backend, location = BackendFile, dataURL
+	}
+	if rest, ok := strings.CutPrefix(location, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			location = filepath.Join(home, rest)
+		}
+	}
+	return strings.ToLower(backend), location
+}
+
+// FormatURL returns the data URL for a location in a backend. Paths of JSON
+// files are returned as they are.
+func FormatURL(backend, location string) string {
+	if b, err := Lookup(backend); err == nil && b.Name == BackendFile {
+		return location
+	}
+	return strings.ToLower(backend) + "://" + location
+}
+
+// Open creates the storage a data URL points at, such as a plain path to a
+// JSON file, "sqlite:///home/me/habits.db" or "mem://".
+func Open(dataURL string, opts Options) (Storage, error) {
+	backend, location := ParseURL(dataURL)
+	return New(backend, location, opts)
+}
+
+// New creates the storage for the named backend at location. An empty name
+// selects the file backend.
+func New(backend, location string, opts Options) (Storage, error) {
+	if strings.TrimSpace(backend) == "" {
+		backend = BackendFile
+	}
+	b, err := Lookup(backend)
+	if err != nil {
+		return nil, err
+	}
+	return b.Open(location, opts)
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+		wantErr bool
+	}{
+		{"", "*storage.JSONStorage", false},
+		{"json", "*storage.JSONStorage", false},
+		{"file", "*storage.JSONStorage", false},
+		{"SQLite", "*storage.SQLiteStorage", false},
+		{"events", "*storage.EventStorage", false},
+		{"mem", "*storage.MemoryStorage", false},
+		{"postgres", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			store, err := New(tt.backend, "habits", Options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(%q) error = %v, wantErr %v", tt.backend, err, tt.wantErr)
+			}
+			if !tt.wantErr && reflect.TypeOf(store).String() != tt.want {
+				t.Errorf("New(%q) = %T, want %s", tt.backend, store, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		url          string
+		wantBackend  string
+		wantLocation string
+	}{
+		{"habits.json", "file", "habits.json"},
+		{"/data/habits.json", "file", "/data/habits.json"},
+		{"file:///data/habits.json", "file", "/data/habits.json"},
+		{"SQLite://data/habits.db", "sqlite", "data/habits.db"},
+		{"sqlite://~/habits.db", "sqlite", filepath.Join(home, "habits.db")},
+		{"mem://", "mem", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			backend, location := ParseURL(tt.url)
+			if backend != tt.wantBackend || location != tt.wantLocation {
+				t.Errorf("ParseURL(%q) = %q, %q; want %q, %q", tt.url, backend, location, tt.wantBackend, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestFormatURL(t *testing.T) {
+	if got := FormatURL("json", "habits.json"); got != "habits.json" {
+		t.Errorf("FormatURL(json) = %q, want a plain path", got)
+	}
+	if got := FormatURL("sqlite", "/data/habits.db"); got != "sqlite:///data/habits.db" {
+		t.Errorf("FormatURL(sqlite) = %q", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a taken name to panic")
+		}
+	}()
+	Register(Backend{Name: "json", Open: func(string, Options) (Storage, error) { return nil, nil }})
+}
+
+func TestMemoryStorage(t *testing.T) {
+	store, err := Open("mem://test", Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if store.Exists() {
+		t.Error("Expected a new memory storage to be empty")
+	}
+
+	habits := models.HabitList{{Name: "Exercise", History: []models.Completion{{Date: "2025-01-15"}}}}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	habits[0].History[0].Note = "changed after saving"
+
+	if err := store.Update(func(habits *models.HabitList) error {
+		return habits.Add(models.Habit{Name: "Reading"})
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].ID == "" || loaded[0].History[0].Note != "" {
+		t.Errorf("Load() = %+v, want both habits with IDs and the history as saved", loaded)
+	}
+
+	if err := store.Delete(); err != nil || store.Exists() {
+		t.Errorf("Delete() error = %v, Exists() = %v", err, store.Exists())
+	}
+}
+
+func TestStorage_KeepsVacations(t *testing.T) {
+	memory := map[string]*MemoryStorage{}
+	backends := map[string]func(dir string) Storage{
+		"json":   func(dir string) Storage { return NewJSONStorage(filepath.Join(dir, "habits.json"), Options{}) },
+		"git":    func(dir string) Storage { return NewGitStorage(filepath.Join(dir, "habits.json"), Options{}) },
+		"sqlite": func(dir string) Storage { return NewSQLiteStorage(filepath.Join(dir, "habits.db"), Options{}) },
+		"events": func(dir string) Storage { return NewEventStorage(filepath.Join(dir, "habits.jsonl"), Options{}) },
+		"memory": func(dir string) Storage {
+			if memory[dir] == nil {
+				memory[dir] = NewMemoryStorage(dir)
+			}
+			return memory[dir]
+		},
+		"encrypted": func(dir string) Storage {
+			return NewEncryptedStorage(NewJSONStorage(filepath.Join(dir, "habits.json"), Options{}), passphrase("secret"))
+		},
+	}
+	vacation := models.Break{Start: "2025-08-01", End: "2025-08-15", Reason: models.BreakVacation}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			if name == "git" {
+				setupGit(t)
+			}
+			dir := t.TempDir()
+			store := open(dir)
+			if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if err := store.UpdateData(func(data *Data) error {
+				return data.Vacations.Start(vacation.Start, vacation.End)
+			}); err != nil {
+				t.Fatalf("UpdateData() error = %v", err)
+			}
+
+			// Saving the habits alone keeps the vacations, in a new instance too
+			if err := store.Save(models.HabitList{{Name: "Exercise"}, {Name: "Reading"}}); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			store = open(dir)
+			data, err := store.LoadData()
+			if err != nil || !reflect.DeepEqual(data.Vacations, models.Vacations{vacation}) {
+				t.Fatalf("LoadData() = %+v, %v; want the vacation", data, err)
+			}
+
+			// Loaded habits follow the vacations
+			habits, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			reading, _ := habits.Find("Reading")
+			if reading == nil || !reading.IsExcused("2025-08-10") {
+				t.Errorf("Load() = %+v, want Reading on vacation on 2025-08-10", habits)
+			}
+		})
+	}
+}
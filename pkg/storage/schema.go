@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// SchemaVersion is the version of the data file format written by Save.
+// Version 1 files are a bare JSON array of habits.
+const SchemaVersion = 3
+
+// ErrNewerSchema is returned when a data file was written by a newer version
+// of habit than this one.
+var ErrNewerSchema = errors.New("data file was written by a newer version of habit")
+
+// Metadata describes a data file.
+type Metadata struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// envelope is the top-level structure of a data file.
+type envelope struct {
+	SchemaVersion int              `json:"schema_version"`
+	Metadata      Metadata         `json:"metadata"`
+	Vacations     models.Vacations `json:"vacations,omitempty"`
+	Habits        json.RawMessage  `json:"habits"`
+}
+
+// Migration upgrades the habits of a data file from one schema version to
+// the next. Apply gets the habits as generic JSON objects, so it can rename
+// or reshape fields the current models no longer have. Upgrade gets them as
+// models, for changes that need their methods. Either may be nil; the Apply
+// steps of all pending migrations run before any Upgrade step.
+type Migration struct {
+	From        int    // Schema version the migration upgrades from
+	Description string // What the migration changes
+	Apply       func(habits []map[string]any) ([]map[string]any, error)
+	Upgrade     func(habits models.HabitList) error
+}
+
+// migrations holds the registered migrations by the version they upgrade from.
+var migrations = map[int]Migration{}
+
+// registerMigration adds a migration to the registry. Every version below
+// SchemaVersion must have exactly one.
+func registerMigration(m Migration) {
+	if _, ok := migrations[m.From]; ok {
+		panic(fmt.Sprintf("duplicate migration from schema version %d", m.From))
+	}
+	migrations[m.From] = m
+}
+
+func init() {
+	registerMigration(Migration{
+		From:        1,
+		Description: "wrap the habit list in a versioned envelope",
+	})
+	registerMigration(Migration{
+		From:        2,
+		Description: "build completion histories and give every habit an ID",
+		Upgrade: func(habits models.HabitList) error {
+			if err := habits.MigrateHistory(); err != nil {
+				return err
+			}
+			habits.EnsureIDs()
+			return nil
+		},
+	})
+}
+
+// Decode parses the contents of a data file of any supported schema version
+// and returns its habits and vacations in the current format. It does not
+// modify the file.
+func Decode(data []byte) (Data, error) {
+	decoded, _, _, err := decode(data)
+	return decoded, err
+}
+
+// decode parses a data file, returning its habits and vacations, metadata and
+// the schema version it was written with.
+func decode(data []byte) (Data, Metadata, int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Data{Habits: models.HabitList{}}, Metadata{}, SchemaVersion, nil
+	}
+
+	var file envelope
+	if data[0] == '[' {
+		file = envelope{SchemaVersion: 1, Habits: data}
+	} else {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return Data{}, Metadata{}, 0, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		if file.SchemaVersion < 1 {
+			return Data{}, Metadata{}, 0, fmt.Errorf("data file has no schema version")
+		}
+	}
+
+	if file.SchemaVersion > SchemaVersion {
+		return Data{}, Metadata{}, 0, fmt.Errorf("%w (file schema version %d, supported up to %d); please upgrade habit",
+			ErrNewerSchema, file.SchemaVersion, SchemaVersion)
+	}
+
+	var pending []Migration
+	for version := file.SchemaVersion; version < SchemaVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return Data{}, Metadata{}, 0, fmt.Errorf("no migration from schema version %d", version)
+		}
+		pending = append(pending, m)
+	}
+
+	raw := file.Habits
+	if len(pending) > 0 {
+		var err error
+		if raw, err = migrate(raw, pending); err != nil {
+			return Data{}, Metadata{}, 0, err
+		}
+	}
+
+	habits := models.HabitList{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &habits); err != nil {
+			return Data{}, Metadata{}, 0, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+
+	for _, m := range pending {
+		if m.Upgrade == nil {
+			continue
+		}
+		if err := m.Upgrade(habits); err != nil {
+			return Data{}, Metadata{}, 0, fmt.Errorf("failed to migrate from schema version %d (%s): %w", m.From, m.Description, err)
+		}
+	}
+
+	return Data{Habits: habits, Vacations: file.Vacations}, file.Metadata, file.SchemaVersion, nil
+}
+
+// migrate runs the Apply steps of the pending migrations on raw habits.
+func migrate(raw json.RawMessage, pending []Migration) (json.RawMessage, error) {
+	var habits []map[string]any
+	if err := json.Unmarshal(raw, &habits); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	for _, m := range pending {
+		if m.Apply == nil {
+			continue
+		}
+		var err error
+		if habits, err = m.Apply(habits); err != nil {
+			return nil, fmt.Errorf("failed to migrate from schema version %d (%s): %w", m.From, m.Description, err)
+		}
+	}
+
+	return json.Marshal(habits)
+}
+
+// Encode returns habits and vacations as the contents of a JSON data file,
+// whichever backend they were loaded from, recording now as its creation
+// time.
+func Encode(data Data, now time.Time) ([]byte, error) {
+	return encode(data, Metadata{CreatedAt: now, UpdatedAt: now})
+}
+
+// encode builds the contents of a data file in the current schema version.
+func encode(data Data, meta Metadata) ([]byte, error) {
+	// Habits saved without going through Load may lack IDs, which files of
+	// the current version always have
+	habits := slices.Clone(data.Habits)
+	if habits == nil {
+		habits = models.HabitList{}
+	}
+	habits.EnsureIDs()
+	raw, err := json.Marshal(habits)
+	if err != nil {
+		return nil, err
+	}
+	file := envelope{SchemaVersion: SchemaVersion, Metadata: meta, Vacations: data.Vacations, Habits: raw}
+	return json.MarshalIndent(file, "", "  ")
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func TestMigrations_Registered(t *testing.T) {
+	for version := 1; version < SchemaVersion; version++ {
+		if _, ok := migrations[version]; !ok {
+			t.Errorf("No migration registered from schema version %d", version)
+		}
+	}
+}
+
+func TestJSONStorage_SaveWritesEnvelope(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+	at := time.Date(2025, 1, 15, 7, 30, 0, 0, time.UTC)
+	store := NewJSONStorage(testFile, Options{Clock: &models.FixedClock{Time: at}})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(testFile)
+	var file envelope
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Saved file is not an envelope: %v", err)
+	}
+	if file.SchemaVersion != SchemaVersion {
+		t.Errorf("schema_version = %d, want %d", file.SchemaVersion, SchemaVersion)
+	}
+	if !file.Metadata.CreatedAt.Equal(at) || !file.Metadata.UpdatedAt.Equal(at) {
+		t.Errorf("metadata = %+v, want both times at %v from the clock", file.Metadata, at)
+	}
+}
+
+func TestJSONStorage_LoadUpgradesOldSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+
+	legacy := `[{"name": "Exercise", "history": [{"date": "2025-01-15"}]}]`
+	if err := os.WriteFile(testFile, []byte(legacy), 0640); err != nil {
+		t.Fatalf("Failed to create legacy file: %v", err)
+	}
+	os.Chmod(testFile, 0640)
+
+	habits, err := NewJSONStorage(testFile, Options{}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(habits) != 1 || habits[0].Name != "Exercise" {
+		t.Fatalf("Load() = %+v, want Exercise", habits)
+	}
+
+	backup, err := os.ReadFile(testFile + ".v1.bak")
+	if err != nil {
+		t.Fatalf("Expected a pre-migration backup: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Errorf("Backup = %q, want the original file", backup)
+	}
+	if info, err := os.Stat(testFile + ".v1.bak"); err == nil && info.Mode().Perm() != 0640 {
+		t.Errorf("Backup mode = %v, want the data file's 0640", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(testFile)
+	var file envelope
+	if err := json.Unmarshal(data, &file); err != nil || file.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected the file to be upgraded to schema version %d, got %s", SchemaVersion, data)
+	}
+}
+
+func TestJSONStorage_LoadRefusesNewerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "habits.json")
+
+	future := `{"schema_version": 99, "metadata": {}, "habits": []}`
+	if err := os.WriteFile(testFile, []byte(future), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	_, err := NewJSONStorage(testFile, Options{}).Load()
+	if !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("Load() error = %v, want ErrNewerSchema", err)
+	}
+
+	data, _ := os.ReadFile(testFile)
+	if string(data) != future {
+		t.Error("Expected a newer file to be left untouched")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"bare list", `[{"name": "Exercise"}]`, 1, false},
+		{"envelope", `{"schema_version": 3, "habits": [{"name": "Exercise"}, {"name": "Reading"}]}`, 2, false},
+		{"older envelope", `{"schema_version": 2, "habits": [{"name": "Exercise"}]}`, 1, false},
+		{"no version", `{"habits": []}`, 0, true},
+		{"newer version", `{"schema_version": 99, "habits": []}`, 0, true},
+		{"invalid JSON", `{`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Decode([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(data.Habits) != tt.want {
+				t.Errorf("Decode() returned %d habit(s), want %d", len(data.Habits), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode_MigratesOnlyOlderVersions(t *testing.T) {
+	habit := `{"name": "Exercise", "last_done": "2025-01-15", "streak": 3}`
+
+	older, err := Decode([]byte(`{"schema_version": 2, "habits": [` + habit + `]}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if h := older.Habits[0]; len(h.History) != 3 || h.ID == "" {
+		t.Errorf("Decode() of schema version 2 = %+v, want a history and an ID", h)
+	}
+
+	current, err := Decode([]byte(`{"schema_version": 3, "habits": [` + habit + `]}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if h := current.Habits[0]; len(h.History) != 0 || h.ID != "" {
+		t.Errorf("Decode() of the current schema version = %+v, want it as stored", h)
+	}
+}
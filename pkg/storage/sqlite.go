@@ -0,0 +1,522 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteSchemaVersion is the version of the database layout, stored in
+// PRAGMA user_version.
+const SQLiteSchemaVersion = 1
+
+// sqliteSchema creates the tables of an empty database. Each habit is a row
+// whose data column holds its fields other than the history as JSON; the
+// history is kept in its own table so it can grow without rewriting habits.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS habits (
+	id       TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS completions (
+	habit_id  TEXT NOT NULL REFERENCES habits (id) ON DELETE CASCADE,
+	date      TEXT NOT NULL,
+	timestamp TEXT NOT NULL DEFAULT '',
+	amount    REAL NOT NULL DEFAULT 0,
+	note      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS completions_habit_date ON completions (habit_id, date);
+`
+
+// SQLiteStorage implements habit storage in a SQLite database, using a
+// pure-Go driver so no cgo is needed. SQLite's own locking serializes
+// concurrent habit processes; Update holds a write transaction throughout.
+type SQLiteStorage struct {
+	filePath    string
+	lockTimeout time.Duration
+	clock       models.Clock
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendSQLite,
+		Description: "SQLite database",
+		Extension:   ".db",
+		Open:        func(location string, opts Options) (Storage, error) { return NewSQLiteStorage(location, opts), nil },
+	})
+}
+
+// NewSQLiteStorage creates a new SQLite storage instance.
+func NewSQLiteStorage(filePath string, opts Options) *SQLiteStorage {
+	return &SQLiteStorage{
+		filePath:    filePath,
+		lockTimeout: opts.lockTimeout(),
+		clock:       opts.clock(),
+	}
+}
+
+// SetLockTimeout sets how long to wait for another process to release the
+// database.
+func (s *SQLiteStorage) SetLockTimeout(timeout time.Duration) {
+	s.lockTimeout = timeout
+}
+
+// Load reads all habits from the database, following its vacations.
+func (s *SQLiteStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData reads all habits and vacations from the database.
+func (s *SQLiteStorage) LoadData() (Data, error) {
+	if !s.Exists() {
+		return Data{Habits: models.HabitList{}}, nil
+	}
+
+	var data Data
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		data, err = readData(tx)
+		return err
+	})
+	if err != nil {
+		return Data{}, err
+	}
+	return data, nil
+}
+
+// Save replaces all habits in the database in one transaction, keeping its
+// vacations.
+func (s *SQLiteStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData replaces all habits and vacations in the database in one
+// transaction.
+func (s *SQLiteStorage) SaveData(data Data) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		return writeData(tx, data, s.clock.Now())
+	})
+}
+
+// Update changes the habits like UpdateData.
+func (s *SQLiteStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData loads the habits and vacations, passes them to fn and saves the
+// result in one write transaction. Nothing is saved if fn returns an error,
+// which UpdateData returns unchanged.
+func (s *SQLiteStorage) UpdateData(fn func(*Data) error) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		data, err := readData(tx)
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+		return writeData(tx, data, s.clock.Now())
+	})
+}
+
+// Delete removes the database file.
+func (s *SQLiteStorage) Delete() error {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(s.filePath + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists checks if the database file exists.
+func (s *SQLiteStorage) Exists() bool {
+	_, err := os.Stat(s.filePath)
+	return err == nil
+}
+
+// GetPath returns the database file path.
+func (s *SQLiteStorage) GetPath() string {
+	return s.filePath
+}
+
+// withTx opens the database, creating it if needed, and runs fn in a write
+// transaction that is committed if fn succeeds.
+func (s *SQLiteStorage) withTx(fn func(tx *sql.Tx) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", s.dsn())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return s.lockError(fmt.Errorf("failed to open database: %w", err))
+	}
+	defer tx.Rollback()
+
+	if err := migrateSQLite(tx); err != nil {
+		return s.lockError(err)
+	}
+	if err := fn(tx); err != nil {
+		return s.lockError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return s.lockError(fmt.Errorf("failed to commit changes: %w", err))
+	}
+	return nil
+}
+
+// dsn returns the data source name to open the database with. The path is
+// given as a percent-encoded file: URI, so a '?' or '#' in it is not taken
+// for the start of the options. _txlock=immediate takes the write lock when
+// a transaction begins, so two processes cannot both read the same state and
+// then overwrite each other's changes.
+func (s *SQLiteStorage) dsn() string {
+	path := filepath.ToSlash(s.filePath)
+	if filepath.VolumeName(s.filePath) != "" {
+		path = "/" + path // file:///C:/... on Windows
+	}
+	uri := url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)&_txlock=immediate", s.lockTimeout.Milliseconds()),
+	}
+	return uri.String()
+}
+
+// purgeHistory rebuilds the database file, so pages that held deleted rows
+// keep nothing of them.
+func (s *SQLiteStorage) purgeHistory() error {
+	if !s.Exists() {
+		return nil
+	}
+	db, err := sql.Open("sqlite", s.dsn())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return s.lockError(fmt.Errorf("failed to compact database: %w", err))
+	}
+	return nil
+}
+
+// lockError turns SQLite's "database is locked" into ErrLocked.
+func (s *SQLiteStorage) lockError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY {
+		return fmt.Errorf("%w (%s was held for longer than the %s timeout)", ErrLocked, s.filePath, s.lockTimeout)
+	}
+	return err
+}
+
+// migrateSQLite creates the tables of a new database and refuses databases
+// written by a newer version.
+func migrateSQLite(tx *sql.Tx) error {
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > SQLiteSchemaVersion {
+		return fmt.Errorf("%w (database schema version %d, supported up to %d); please upgrade habit",
+			ErrNewerSchema, version, SQLiteSchemaVersion)
+	}
+	if version == SQLiteSchemaVersion {
+		return nil
+	}
+
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", SQLiteSchemaVersion)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+	return nil
+}
+
+// readData loads all habits and the vacations, which are kept as JSON in
+// the meta table.
+func readData(tx *sql.Tx) (Data, error) {
+	habits, err := readHabits(tx)
+	if err != nil {
+		return Data{}, err
+	}
+
+	var vacations models.Vacations
+	var value string
+	err = tx.QueryRow("SELECT value FROM meta WHERE key = 'vacations'").Scan(&value)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return Data{}, fmt.Errorf("failed to read vacations: %w", err)
+	default:
+		if err := json.Unmarshal([]byte(value), &vacations); err != nil {
+			return Data{}, fmt.Errorf("failed to parse vacations: %w", err)
+		}
+	}
+	return Data{Habits: habits, Vacations: vacations}, nil
+}
+
+// writeData makes the stored habits and vacations match the given ones.
+func writeData(tx *sql.Tx, data Data, now time.Time) error {
+	if err := writeHabits(tx, data.Habits, now); err != nil {
+		return err
+	}
+	if len(data.Vacations) == 0 {
+		if _, err := tx.Exec("DELETE FROM meta WHERE key = 'vacations'"); err != nil {
+			return fmt.Errorf("failed to save vacations: %w", err)
+		}
+		return nil
+	}
+	value, err := json.Marshal(data.Vacations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vacations: %w", err)
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('vacations', ?)", string(value)); err != nil {
+		return fmt.Errorf("failed to save vacations: %w", err)
+	}
+	return nil
+}
+
+// readHabits loads all habits and their histories.
+func readHabits(tx *sql.Tx) (models.HabitList, error) {
+	rows, err := tx.Query("SELECT id, name, data FROM habits ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read habits: %w", err)
+	}
+	defer rows.Close()
+
+	habits := models.HabitList{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var id, name, data string
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			return nil, fmt.Errorf("failed to read habits: %w", err)
+		}
+		var habit models.Habit
+		if err := json.Unmarshal([]byte(data), &habit); err != nil {
+			return nil, fmt.Errorf("failed to parse habit '%s': %w", name, err)
+		}
+		habit.ID, habit.Name = id, name
+		index[id] = len(habits)
+		habits = append(habits, habit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read habits: %w", err)
+	}
+
+	completions, err := tx.Query("SELECT habit_id, date, timestamp, amount, note FROM completions ORDER BY habit_id, date, rowid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read completions: %w", err)
+	}
+	defer completions.Close()
+
+	for completions.Next() {
+		var id, timestamp string
+		var c models.Completion
+		if err := completions.Scan(&id, &c.Date, &timestamp, &c.Amount, &c.Note); err != nil {
+			return nil, fmt.Errorf("failed to read completions: %w", err)
+		}
+		if timestamp != "" {
+			if c.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+				return nil, fmt.Errorf("invalid completion timestamp '%s': %w", timestamp, err)
+			}
+		}
+		if i, ok := index[id]; ok {
+			habits[i].History = append(habits[i].History, c)
+		}
+	}
+	if err := completions.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read completions: %w", err)
+	}
+
+	return habits, nil
+}
+
+// storedCompletion is a row of the completions table.
+type storedCompletion struct {
+	rowid     int64
+	date      string
+	timestamp string
+	amount    float64
+	note      string
+}
+
+// newStoredCompletion returns the row that holds c.
+func newStoredCompletion(c models.Completion) storedCompletion {
+	row := storedCompletion{date: c.Date, amount: c.Amount, note: c.Note}
+	if !c.Timestamp.IsZero() {
+		row.timestamp = c.Timestamp.Format(time.RFC3339Nano)
+	}
+	return row
+}
+
+// sameCompletion reports whether two rows hold the same completion.
+func sameCompletion(a, b storedCompletion) bool {
+	return a.date == b.date && a.timestamp == b.timestamp && a.amount == b.amount && a.note == b.note
+}
+
+// writeHabits makes the stored habits match the given ones. Only the habit
+// rows and completions that differ are written, so marking one habit does
+// not rewrite every history. now is recorded as the time of the change.
+func writeHabits(tx *sql.Tx, habits models.HabitList, now time.Time) error {
+	// Habits saved without going through Load may lack IDs
+	habits = slices.Clone(habits)
+	habits.EnsureIDs()
+
+	type storedHabit struct {
+		position   int
+		name, data string
+	}
+	stored := make(map[string]storedHabit)
+	rows, err := tx.Query("SELECT id, position, name, data FROM habits")
+	if err != nil {
+		return fmt.Errorf("failed to read habits: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		var h storedHabit
+		if err := rows.Scan(&id, &h.position, &h.name, &h.data); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read habits: %w", err)
+		}
+		stored[id] = h
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read habits: %w", err)
+	}
+
+	storedHistory, err := readCompletionRows(tx)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(habits))
+	for i, habit := range habits {
+		kept[habit.ID] = true
+		history := habit.History
+		habit.History = nil
+		data, err := json.Marshal(habit)
+		if err != nil {
+			return fmt.Errorf("failed to marshal habit '%s': %w", habit.Name, err)
+		}
+		if old, ok := stored[habit.ID]; !ok || old != (storedHabit{i, habit.Name, string(data)}) {
+			if _, err := tx.Exec(`INSERT INTO habits (id, position, name, data) VALUES (?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET position = excluded.position, name = excluded.name, data = excluded.data`,
+				habit.ID, i, habit.Name, string(data)); err != nil {
+				return fmt.Errorf("failed to save habit '%s': %w", habit.Name, err)
+			}
+		}
+		if err := writeHistory(tx, habit.ID, history, storedHistory[habit.ID]); err != nil {
+			return fmt.Errorf("failed to save history of '%s': %w", habit.Name, err)
+		}
+	}
+
+	// Completions of removed habits go with them through ON DELETE CASCADE
+	for id := range stored {
+		if kept[id] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM habits WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete habit: %w", err)
+		}
+	}
+
+	return writeMetadata(tx, now)
+}
+
+// readCompletionRows returns the stored completions of each habit, in the
+// order readHabits loads them.
+func readCompletionRows(tx *sql.Tx) (map[string][]storedCompletion, error) {
+	rows, err := tx.Query("SELECT rowid, habit_id, date, timestamp, amount, note FROM completions ORDER BY habit_id, date, rowid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read completions: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[string][]storedCompletion)
+	for rows.Next() {
+		var id string
+		var c storedCompletion
+		if err := rows.Scan(&c.rowid, &id, &c.date, &c.timestamp, &c.amount, &c.note); err != nil {
+			return nil, fmt.Errorf("failed to read completions: %w", err)
+		}
+		history[id] = append(history[id], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read completions: %w", err)
+	}
+	return history, nil
+}
+
+// writeHistory makes the stored completions of a habit match its history.
+// Completions are paired day by day, so a changed completion is updated in
+// its row and the order of completions on the same day is kept.
+func writeHistory(tx *sql.Tx, habitID string, history []models.Completion, stored []storedCompletion) error {
+	byDate := make(map[string][]storedCompletion)
+	for _, c := range stored {
+		byDate[c.date] = append(byDate[c.date], c)
+	}
+
+	for _, completion := range history {
+		c := newStoredCompletion(completion)
+		if old := byDate[c.date]; len(old) > 0 {
+			byDate[c.date] = old[1:]
+			if sameCompletion(old[0], c) {
+				continue
+			}
+			if _, err := tx.Exec("UPDATE completions SET timestamp = ?, amount = ?, note = ? WHERE rowid = ?",
+				c.timestamp, c.amount, c.note, old[0].rowid); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO completions (habit_id, date, timestamp, amount, note) VALUES (?, ?, ?, ?, ?)",
+			habitID, c.date, c.timestamp, c.amount, c.note); err != nil {
+			return err
+		}
+	}
+
+	for _, left := range byDate {
+		for _, c := range left {
+			if _, err := tx.Exec("DELETE FROM completions WHERE rowid = ?", c.rowid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeMetadata records when the database was created and last changed.
+func writeMetadata(tx *sql.Tx, at time.Time) error {
+	now := at.Format(time.RFC3339)
+	if _, err := tx.Exec("INSERT OR IGNORE INTO meta (key, value) VALUES ('created_at', ?)", now); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('updated_at', ?)", now); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+func TestSQLiteStorage_SaveAndLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewSQLiteStorage(filepath.Join(tmpDir, "habits.db"), Options{})
+
+	if store.Exists() {
+		t.Fatal("Expected no database before Save()")
+	}
+	loaded, err := store.Load()
+	if err != nil || len(loaded) != 0 {
+		t.Fatalf("Load() of a missing database = %v, %v; want empty", loaded, err)
+	}
+
+	at := time.Date(2025, 1, 15, 7, 30, 0, 0, time.UTC)
+	habits := models.HabitList{
+		{
+			ID:       "a1b2c3d4",
+			Name:     "Exercise",
+			LastDone: "2025-01-15",
+			Streak:   2,
+			History: []models.Completion{
+				{Date: "2025-01-14"},
+				{Date: "2025-01-15", Timestamp: at, Note: "5k"},
+			},
+			Tags:    []string{"health"},
+			Breaks:  []models.Break{{Start: "2025-01-01", End: "2025-01-05", Reason: models.BreakVacation}},
+			Freezes: 2,
+		},
+		{ID: "0000ffff", Name: "Water", Target: 8, Unit: "glasses", History: []models.Completion{{Date: "2025-01-15", Amount: 5}}},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, habits) {
+		t.Errorf("Load() = %+v\nwant %+v", loaded, habits)
+	}
+}
+
+func TestSQLiteStorage_Update(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewSQLiteStorage(filepath.Join(tmpDir, "habits.db"), Options{})
+
+	if err := store.Save(models.HabitList{{Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	err := store.Update(func(habits *models.HabitList) error {
+		return habits.Add(models.Habit{Name: "Reading"})
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	errStop := errors.New("stop")
+	err = store.Update(func(habits *models.HabitList) error {
+		*habits = nil
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Update() error = %v, want the callback's error", err)
+	}
+
+	habits, _ := store.Load()
+	if len(habits) != 2 || habits[0].ID == "" {
+		t.Errorf("Load() = %+v, want Exercise and Reading with IDs", habits)
+	}
+}
+
+func TestSQLiteStorage_RefusesNewerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.db")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatalf("Failed to set version: %v", err)
+	}
+	db.Close()
+
+	if _, err := NewSQLiteStorage(path, Options{}).Load(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Load() error = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestSQLiteStorage_WritesOnlyChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "habits.db")
+	store := NewSQLiteStorage(path, Options{})
+
+	habits := models.HabitList{
+		{ID: "aaaa0001", Name: "Exercise", History: []models.Completion{{Date: "2025-01-14"}, {Date: "2025-01-15"}}},
+		{ID: "aaaa0002", Name: "Reading", History: []models.Completion{{Date: "2025-01-15"}}},
+		{ID: "aaaa0003", Name: "Water", Target: 8, History: []models.Completion{{Date: "2025-01-15", Amount: 3}}},
+	}
+	if err := store.Save(habits); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Record every write to the tables from here on
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE writes (what TEXT);
+		CREATE TRIGGER habit_insert AFTER INSERT ON habits BEGIN INSERT INTO writes VALUES ('insert habit ' || new.name); END;
+		CREATE TRIGGER habit_update AFTER UPDATE ON habits BEGIN INSERT INTO writes VALUES ('update habit ' || new.name); END;
+		CREATE TRIGGER habit_delete AFTER DELETE ON habits BEGIN INSERT INTO writes VALUES ('delete habit ' || old.name); END;
+		CREATE TRIGGER completion_insert AFTER INSERT ON completions BEGIN INSERT INTO writes VALUES ('insert ' || new.habit_id || ' ' || new.date); END;
+		CREATE TRIGGER completion_update AFTER UPDATE ON completions BEGIN INSERT INTO writes VALUES ('update ' || new.habit_id || ' ' || new.date); END;
+		CREATE TRIGGER completion_delete AFTER DELETE ON completions BEGIN INSERT INTO writes VALUES ('delete ' || old.habit_id || ' ' || old.date); END;`)
+	if err != nil {
+		t.Fatalf("Failed to create triggers: %v", err)
+	}
+
+	err = store.Update(func(habits *models.HabitList) error {
+		(*habits)[0].History = append((*habits)[0].History, models.Completion{Date: "2025-01-16"})
+		(*habits)[1].History = nil
+		(*habits)[2].History[0].Amount = 5
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	rows, err := db.Query("SELECT what FROM writes ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("Failed to read writes: %v", err)
+	}
+	defer rows.Close()
+	var writes []string
+	for rows.Next() {
+		var what string
+		rows.Scan(&what)
+		writes = append(writes, what)
+	}
+	want := []string{"insert aaaa0001 2025-01-16", "delete aaaa0002 2025-01-15", "update aaaa0003 2025-01-15"}
+	if !reflect.DeepEqual(writes, want) {
+		t.Errorf("Update() wrote %q, want %q", writes, want)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded[0].History) != 3 || len(loaded[1].History) != 0 || loaded[2].History[0].Amount != 5 {
+		t.Errorf("Load() = %+v, want the changes saved", loaded)
+	}
+
+	err = store.Update(func(habits *models.HabitList) error {
+		*habits = models.HabitList{(*habits)[2], (*habits)[0]}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := habitNames(t, store); !reflect.DeepEqual(got, []string{"Water", "Exercise"}) {
+		t.Errorf("Load() = %v, want Water,Exercise", got)
+	}
+}
+
+func TestSQLiteStorage_PathWithURICharacters(t *testing.T) {
+	name := "my habits #1?.db"
+	if runtime.GOOS == "windows" {
+		name = "my habits #1%.db" // '?' is not allowed in Windows file names
+	}
+	path := filepath.Join(t.TempDir(), name)
+	store := NewSQLiteStorage(path, Options{})
+
+	if err := store.Save(models.HabitList{{ID: "aaaa0001", Name: "Exercise"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("database not created at %s: %v", path, err)
+	}
+	if got := habitNames(t, store); len(got) != 1 || got[0] != "Exercise" {
+		t.Errorf("Load() = %v, want Exercise", got)
+	}
+}
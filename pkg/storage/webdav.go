@@ -0,0 +1,531 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforgood-org/cli-habit-tracker-go/pkg/models"
+)
+
+// webdavAttempts is how often a save is tried when the data file keeps
+// changing on the server in between.
+const webdavAttempts = 3
+
+// webdavTimeout bounds each request to the server, so an unreachable server
+// falls back to the cache in reasonable time.
+const webdavTimeout = 15 * time.Second
+
+// ErrOffline is returned when a WebDAV server cannot be reached and the
+// cache cannot stand in for it.
+var ErrOffline = errors.New("WebDAV server cannot be reached")
+
+// errPrecondition is returned by put when the data file changed on the
+// server since it was read.
+var errPrecondition = errors.New("data file changed on the server")
+
+// SyncStatus tells how storage that works offline stands with its server
+// after the last operation.
+type SyncStatus struct {
+	Offline   bool                   // The server could not be reached
+	Pending   bool                   // Changes are kept in the cache until the server is reachable
+	Conflicts []models.MergeConflict // Resolved in favor of local changes when reconciling
+}
+
+// WebDAVStorage keeps habits in a JSON data file on a WebDAV server such as
+// Nextcloud. Saves send If-Match with the ETag of the version they are based
+// on, so a change saved elsewhere in between is never overwritten: the
+// server refuses the save, and the change is merged into the server's
+// version with models.Merge and saved again.
+//
+// A copy of the habits is cached locally. While the server cannot be
+// reached, habits are loaded from the cache and changes are kept there; the
+// next load or save that reaches the server reconciles them by merging them
+// with whatever changed on the server in the meantime.
+type WebDAVStorage struct {
+	fileURL     string // URL of the data file, without credentials
+	username    string
+	password    string
+	cachePath   string
+	client      *http.Client
+	lockTimeout time.Duration
+	clock       models.Clock
+
+	mu     sync.Mutex
+	status SyncStatus
+}
+
+// webdavCache is the local copy of a data file on a WebDAV server.
+type webdavCache struct {
+	ETag  string          `json:"etag,omitempty"`  // ETag of Base on the server; empty if the file did not exist
+	Base  json.RawMessage `json:"base,omitempty"`  // Data file as last read from or saved to the server
+	Local json.RawMessage `json:"local,omitempty"` // Data file changed while offline, if any
+}
+
+func init() {
+	Register(Backend{
+		Name:        BackendWebDAV,
+		Description: "JSON file on a WebDAV server over HTTP",
+		Extension:   ".json",
+		Open: func(location string, opts Options) (Storage, error) {
+			return NewWebDAVStorage("http://"+location, "", opts)
+		},
+	})
+	Register(Backend{
+		Name:        BackendWebDAVS,
+		Description: "JSON file on a WebDAV server over HTTPS, e.g. Nextcloud",
+		Extension:   ".json",
+		Open: func(location string, opts Options) (Storage, error) {
+			return NewWebDAVStorage("https://"+location, "", opts)
+		},
+	})
+}
+
+// NewWebDAVStorage creates storage for the data file at fileURL, an http or
+// https URL that may include a user name and password. The habits are cached
+// at cachePath, or in the user's cache directory if it is empty.
+func NewWebDAVStorage(fileURL, cachePath string, opts Options) (*WebDAVStorage, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid WebDAV URL '%s' (expected e.g. webdavs://cloud.example.com/remote.php/dav/files/me/habits.json)", fileURL)
+	}
+	if u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("WebDAV URL '%s' must name the data file, e.g. .../habits.json", u.Redacted())
+	}
+
+	s := &WebDAVStorage{
+		cachePath:   cachePath,
+		client:      &http.Client{Timeout: webdavTimeout},
+		lockTimeout: opts.lockTimeout(),
+		clock:       opts.clock(),
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+		u.User = nil
+	}
+	s.fileURL = u.String()
+
+	if s.cachePath == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a cache directory: %w", err)
+		}
+		sum := sha256.Sum256([]byte(s.fileURL))
+		s.cachePath = filepath.Join(dir, "habit-tracker", "webdav-"+hex.EncodeToString(sum[:8])+".json")
+	}
+	return s, nil
+}
+
+// SetLockTimeout sets how long to wait for another process using the cache.
+func (s *WebDAVStorage) SetLockTimeout(timeout time.Duration) {
+	s.lockTimeout = timeout
+}
+
+// Load returns the habits on the server, following its vacations, like
+// LoadData.
+func (s *WebDAVStorage) Load() (models.HabitList, error) {
+	return loadHabits(s)
+}
+
+// LoadData returns the habits and vacations on the server, after saving
+// changes kept in the cache while offline. If the server cannot be reached,
+// the cached ones are returned.
+func (s *WebDAVStorage) LoadData() (Data, error) {
+	var data Data
+	err := s.withCache(func(cache *webdavCache) error {
+		var err error
+		data, err = s.sync(cache, nil)
+		return err
+	})
+	return data, err
+}
+
+// Save replaces the habits on the server, keeping the vacations.
+func (s *WebDAVStorage) Save(habits models.HabitList) error {
+	return saveHabits(s, habits)
+}
+
+// SaveData replaces the habits and vacations on the server.
+func (s *WebDAVStorage) SaveData(data Data) error {
+	return s.UpdateData(func(current *Data) error {
+		*current = data
+		return nil
+	})
+}
+
+// Update changes the habits like UpdateData.
+func (s *WebDAVStorage) Update(fn func(*models.HabitList) error) error {
+	return updateHabits(s, fn)
+}
+
+// UpdateData loads the habits and vacations, passes them to fn and saves the
+// result. If the data file changes on the server before the result is saved,
+// the change made by fn is merged into the new version. If the server cannot
+// be reached, the result is kept in the cache. Nothing is saved if fn returns
+// an error, which UpdateData returns unchanged.
+func (s *WebDAVStorage) UpdateData(fn func(*Data) error) error {
+	return s.withCache(func(cache *webdavCache) error {
+		_, err := s.sync(cache, fn)
+		return err
+	})
+}
+
+// Delete removes the data file from the server and the cache.
+func (s *WebDAVStorage) Delete() error {
+	lock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	resp, err := s.request(http.MethodDelete, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete %s: %s", s.fileURL, resp.Status)
+	}
+	if err := os.Remove(s.cachePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// purgeHistory drops the cached copy of the server's version while encrypted
+// changes wait in the cache to be saved and that copy is not encrypted, as
+// after encrypting offline. Encrypted changes are never merged with it, so
+// nothing is lost.
+func (s *WebDAVStorage) purgeHistory() error {
+	return s.withCache(func(cache *webdavCache) error {
+		if cache.Base == nil || cache.Local == nil {
+			return nil
+		}
+		base, err := decodeCached(cache.Base)
+		if err != nil {
+			return err
+		}
+		local, err := decodeCached(cache.Local)
+		if err != nil {
+			return err
+		}
+		if IsEncryptedList(base.Habits) || !IsEncryptedList(local.Habits) {
+			return nil
+		}
+		cache.Base = nil
+		return s.writeCache(cache)
+	})
+}
+
+// Exists checks if the data file exists on the server, or in the cache if
+// the server cannot be reached.
+func (s *WebDAVStorage) Exists() bool {
+	resp, err := s.request(http.MethodHead, nil, nil)
+	if err != nil {
+		_, statErr := os.Stat(s.cachePath)
+		return errors.Is(err, ErrOffline) && statErr == nil
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// GetPath returns the URL of the data file, without credentials.
+func (s *WebDAVStorage) GetPath() string {
+	return s.fileURL
+}
+
+// SyncStatus returns how the last operation stood with the server.
+func (s *WebDAVStorage) SyncStatus() SyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// sync brings the cache up to date with the server and, if fn is set, saves
+// the habits and vacations as changed by fn. Changes kept in the cache while
+// offline are merged into the server's version first. If the server cannot
+// be reached, fn changes the cached ones instead. The caller must hold the
+// cache lock.
+func (s *WebDAVStorage) sync(cache *webdavCache, fn func(*Data) error) (Data, error) {
+	base, err := decodeCached(cache.Base)
+	if err != nil {
+		return Data{}, err
+	}
+	local, err := decodeCached(cache.Local)
+	if err != nil {
+		return Data{}, err
+	}
+	pending, etag := cache.Local != nil, cache.ETag
+	if !pending {
+		local = base
+	}
+
+	for attempt := 1; ; attempt++ {
+		remote, remoteETag, err := s.get()
+		if errors.Is(err, ErrOffline) {
+			return s.offline(cache, local, fn)
+		}
+		if err != nil {
+			return Data{}, err
+		}
+
+		data := cloneData(remote)
+		var conflicts []models.MergeConflict
+		if pending && remoteETag != etag {
+			if (IsEncryptedList(local.Habits) || IsEncryptedList(remote.Habits)) && cache.Local != nil {
+				return Data{}, fmt.Errorf("encrypted habits were changed both offline and on the server, "+
+					"which cannot be merged; the offline changes are kept in %s", s.cachePath)
+			}
+			if IsEncryptedList(local.Habits) || IsEncryptedList(remote.Habits) {
+				return Data{}, fmt.Errorf("encrypted habits changed on the server while saving, which cannot be merged; please try again")
+			}
+			if data, conflicts, err = MergeData(base, local, remote); err != nil {
+				return Data{}, fmt.Errorf("failed to merge offline changes: %w", err)
+			}
+		} else if pending {
+			data = cloneData(local)
+		}
+
+		if fn != nil {
+			if err := fn(&data); err != nil {
+				return Data{}, err
+			}
+		}
+		if !pending && (fn == nil || jsonEqual(data, remote)) {
+			s.setStatus(SyncStatus{})
+			return data, s.saveCache(cache, remoteETag, remote, nil)
+		}
+
+		newETag, err := s.put(data, remoteETag)
+		switch {
+		case errors.Is(err, errPrecondition) && attempt < webdavAttempts:
+			// Saved elsewhere in between: merge the result into the new version
+			base, local, etag, pending, fn = remote, data, remoteETag, true, nil
+			continue
+		case errors.Is(err, ErrOffline):
+			s.setStatus(SyncStatus{Offline: true, Pending: true})
+			return data, s.saveCache(cache, remoteETag, remote, &data)
+		case err != nil:
+			return Data{}, err
+		}
+
+		s.setStatus(SyncStatus{Conflicts: conflicts})
+		return data, s.saveCache(cache, newETag, data, nil)
+	}
+}
+
+// offline stands in for the server with the cached habits and vacations,
+// applying fn to them and keeping the result until the server can be reached.
+func (s *WebDAVStorage) offline(cache *webdavCache, local Data, fn func(*Data) error) (Data, error) {
+	if cache.Base == nil && cache.Local == nil {
+		return Data{}, fmt.Errorf("%w and no copy of %s is cached yet", ErrOffline, s.fileURL)
+	}
+	if fn == nil {
+		s.setStatus(SyncStatus{Offline: true, Pending: cache.Local != nil})
+		return local, nil
+	}
+
+	if err := fn(&local); err != nil {
+		return Data{}, err
+	}
+	s.setStatus(SyncStatus{Offline: true, Pending: true})
+	base, err := decodeCached(cache.Base)
+	if err != nil {
+		return Data{}, err
+	}
+	return local, s.saveCache(cache, cache.ETag, base, &local)
+}
+
+// get downloads the data file and its ETag. A missing file holds no habits
+// and has no ETag.
+func (s *WebDAVStorage) get() (Data, string, error) {
+	resp, err := s.request(http.MethodGet, nil, nil)
+	if err != nil {
+		return Data{}, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Data{Habits: models.HabitList{}}, "", nil
+	case resp.StatusCode != http.StatusOK:
+		return Data{}, "", fmt.Errorf("failed to read %s: %s", s.fileURL, resp.Status)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return Data{}, "", fmt.Errorf("WebDAV server sent no ETag for %s, which is needed to save safely", s.fileURL)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Data{}, "", fmt.Errorf("%w: %v", ErrOffline, err)
+	}
+	data, err := Decode(content)
+	if err != nil {
+		return Data{}, "", fmt.Errorf("failed to read %s: %w", s.fileURL, err)
+	}
+	return data, etag, nil
+}
+
+// put uploads data if the data file on the server still has etag, or does
+// not exist yet if etag is empty, and returns the new ETag.
+func (s *WebDAVStorage) put(data Data, etag string) (string, error) {
+	content, err := Encode(data, s.clock.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+
+	resp, err := s.request(http.MethodPut, content, header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return "", errPrecondition
+	case resp.StatusCode == http.StatusConflict:
+		return "", fmt.Errorf("failed to save %s: its folder does not exist on the server", s.fileURL)
+	case resp.StatusCode >= 300:
+		return "", fmt.Errorf("failed to save %s: %s", s.fileURL, resp.Status)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag, nil
+	}
+
+	// Not every server returns the new ETag
+	resp, err = s.request(http.MethodHead, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// request sends a request for the data file. Failures to reach the server,
+// and answers saying it is unavailable, are ErrOffline.
+func (s *WebDAVStorage) request(method string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.fileURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV URL: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOffline, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebDAV server refused access to %s: %s", s.fileURL, resp.Status)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrOffline, resp.Status)
+	}
+	return resp, nil
+}
+
+// withCache runs fn on the cache while holding its lock.
+func (s *WebDAVStorage) withCache(fn func(*webdavCache) error) error {
+	lock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	var cache webdavCache
+	data, err := os.ReadFile(s.cachePath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &cache); err != nil {
+			return fmt.Errorf("invalid WebDAV cache %s: %w", s.cachePath, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read WebDAV cache: %w", err)
+	}
+	return fn(&cache)
+}
+
+// lock takes the exclusive lock on the cache, creating its directory.
+func (s *WebDAVStorage) lock() (*fileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(s.cachePath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return acquireLock(s.cachePath+lockSuffix, true, s.lockTimeout)
+}
+
+// saveCache records the version on the server and any changes not yet saved
+// there. The caller must hold the cache lock.
+func (s *WebDAVStorage) saveCache(cache *webdavCache, etag string, base Data, local *Data) error {
+	encoded, err := Encode(base, s.clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	cache.ETag, cache.Base, cache.Local = etag, encoded, nil
+	if local != nil {
+		if cache.Local, err = Encode(*local, s.clock.Now()); err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+	}
+
+	return s.writeCache(cache)
+}
+
+// writeCache writes the cache file. The caller must hold the cache lock.
+func (s *WebDAVStorage) writeCache(cache *webdavCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := replaceFile(s.cachePath, data, 0600, false); err != nil {
+		return fmt.Errorf("failed to write WebDAV cache: %w", err)
+	}
+	return nil
+}
+
+// setStatus records the outcome of an operation.
+func (s *WebDAVStorage) setStatus(status SyncStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// decodeCached parses a data file kept in the cache; nothing cached holds
+// no habits.
+func decodeCached(content json.RawMessage) (Data, error) {
+	if content == nil {
+		return Data{Habits: models.HabitList{}}, nil
+	}
+	data, err := Decode(content)
+	if err != nil {
+		return Data{}, fmt.Errorf("invalid WebDAV cache: %w", err)
+	}
+	return data, nil
+}